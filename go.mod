@@ -1,5 +1,7 @@
 module github.com/maxfish/go-libs
 
-go 1.16
+go 1.18
 
 require github.com/go-gl/mathgl v1.0.0
+
+require golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f // indirect
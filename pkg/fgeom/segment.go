@@ -0,0 +1,5 @@
+package fgeom
+
+type Segment struct {
+	A, B Point
+}
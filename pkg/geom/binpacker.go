@@ -0,0 +1,63 @@
+package geom
+
+import "github.com/maxfish/go-libs/pkg/imath"
+
+// BinPacker is implemented by all the rect bin packing algorithms.
+// The dimensions of the rects it returns include the padding.
+type BinPacker interface {
+	// Pack places as many of the passed rects as possible
+	Pack(inputRects []RectNode) *BinPackResult
+	// Insert places a single rect of the given size. It returns false if the rect doesn't fit.
+	Insert(width, height int) (RectNode, bool)
+	// Occupancy computes the ratio of used surface area
+	Occupancy() float32
+}
+
+// BinPackResult is the result returned by all the BinPacker implementations
+type BinPackResult = MaxRectsBinResult
+
+// newBinPackResult puts together the result of a packing, computing the total area used by the rects
+func newBinPackResult(placedRects, notPlacedRects []RectNode) *BinPackResult {
+	result := &BinPackResult{
+		PlacedRects:    placedRects,
+		NotPlacedRects: notPlacedRects,
+	}
+	for i := 0; i < len(placedRects); i++ {
+		rect := placedRects[i]
+		result.Width = imath.Max(result.Width, rect.Right())
+		result.Height = imath.Max(result.Height, rect.Bottom())
+	}
+	return result
+}
+
+// occupancy computes the ratio of the bin covered by the rects
+func occupancy(rects []RectNode, binWidth, binHeight int) float32 {
+	usedSurfaceArea := 0
+	for i := 0; i < len(rects); i++ {
+		usedSurfaceArea += rects[i].W * rects[i].H
+	}
+	return float32(usedSurfaceArea) / float32(binWidth*binHeight)
+}
+
+// maxRectsBinPackerWithHeuristic adapts MaxRectsBinPacker to the BinPacker interface
+type maxRectsBinPackerWithHeuristic struct {
+	packer *MaxRectsBinPacker
+	method FreeRectChoiceHeuristic
+}
+
+// WithHeuristic returns a BinPacker which packs the rects in this bin, always using the passed heuristic
+func (mr *MaxRectsBinPacker) WithHeuristic(method FreeRectChoiceHeuristic) BinPacker {
+	return &maxRectsBinPackerWithHeuristic{packer: mr, method: method}
+}
+
+func (p *maxRectsBinPackerWithHeuristic) Pack(inputRects []RectNode) *BinPackResult {
+	return p.packer.Pack(inputRects, p.method)
+}
+
+func (p *maxRectsBinPackerWithHeuristic) Insert(width, height int) (RectNode, bool) {
+	return p.packer.Insert(width, height, p.method)
+}
+
+func (p *maxRectsBinPackerWithHeuristic) Occupancy() float32 {
+	return p.packer.Occupancy()
+}
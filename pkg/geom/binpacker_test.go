@@ -0,0 +1,128 @@
+package geom
+
+import (
+	"fmt"
+	"testing"
+)
+
+type binPackerFactory struct {
+	name      string
+	newPacker func(width, height int, allowRotation bool) BinPacker
+}
+
+var binPackerFactories = []binPackerFactory{
+	{"MaxRects-BSSF", func(width, height int, allowRotation bool) BinPacker {
+		return NewMaxRectsBinPacker(width, height, 0, 0, allowRotation).WithHeuristic(RectBestShortSideFit)
+	}},
+	{"MaxRects-CP", func(width, height int, allowRotation bool) BinPacker {
+		return NewMaxRectsBinPacker(width, height, 0, 0, allowRotation).WithHeuristic(RectContactPointRule)
+	}},
+	{"Skyline-BL", func(width, height int, allowRotation bool) BinPacker {
+		return NewSkylineBinPacker(width, height, 0, 0, allowRotation, SkylineBottomLeft)
+	}},
+	{"Skyline-MW", func(width, height int, allowRotation bool) BinPacker {
+		return NewSkylineBinPacker(width, height, 0, 0, allowRotation, SkylineMinWasteFit)
+	}},
+	{"Guillotine-BAF-SLAS", func(width, height int, allowRotation bool) BinPacker {
+		return NewGuillotineBinPacker(width, height, 0, 0, allowRotation, RectBestAreaFit, SplitShorterLeftoverAxis, true)
+	}},
+	{"Guillotine-BSSF-MINAS", func(width, height int, allowRotation bool) BinPacker {
+		return NewGuillotineBinPacker(width, height, 0, 0, allowRotation, RectBestShortSideFit, SplitMinimizeArea, false)
+	}},
+}
+
+func TestBinPackersPack(t *testing.T) {
+	const binSize = 256
+	for _, allowRotation := range []bool{false, true} {
+		for _, factory := range binPackerFactories {
+			packer := factory.newPacker(binSize, binSize, allowRotation)
+			result := packer.Pack(setupRectNodes(120, 8, 40))
+			errorText := fmt.Sprintf("TestBinPackersPack %s rotation:%v", factory.name, allowRotation)
+			assertValidPacking(t, errorText, binSize, binSize, result.PlacedRects)
+			if len(result.PlacedRects)+len(result.NotPlacedRects) != 120 {
+				t.Errorf("%s failed: %d placed + %d not placed, expecting 120", errorText,
+					len(result.PlacedRects), len(result.NotPlacedRects))
+			}
+			// The bin can't hold all the rects, every packer should fill a good part of it
+			if packer.Occupancy() < 0.6 {
+				t.Errorf("%s failed: occupancy %f", errorText, packer.Occupancy())
+			}
+		}
+	}
+}
+
+func TestBinPackersInsert(t *testing.T) {
+	const binSize = 128
+	for _, factory := range binPackerFactories {
+		packer := factory.newPacker(binSize, binSize, false)
+		placed := make([]RectNode, 0)
+		for _, rect := range setupRectNodes(200, 4, 24) {
+			node, ok := packer.Insert(rect.W, rect.H)
+			if ok {
+				placed = append(placed, node)
+			}
+		}
+		errorText := fmt.Sprintf("TestBinPackersInsert %s", factory.name)
+		assertValidPacking(t, errorText, binSize, binSize, placed)
+		if occupancy(placed, binSize, binSize) != packer.Occupancy() {
+			t.Errorf("%s failed: occupancy %f, expecting %f", errorText, packer.Occupancy(), occupancy(placed, binSize, binSize))
+		}
+	}
+}
+
+func TestGuillotineBinPackerPerfectFit(t *testing.T) {
+	// Four quarters of the bin have to fill it completely, with any split heuristic
+	for split := SplitShorterLeftoverAxis; split <= SplitLongerAxis; split++ {
+		packer := NewGuillotineBinPacker(64, 64, 0, 0, false, RectBestAreaFit, split, true)
+		rects := []RectNode{NewRectNode(0, 32, 32), NewRectNode(1, 32, 32), NewRectNode(2, 32, 32), NewRectNode(3, 32, 32)}
+		result := packer.Pack(rects)
+		if len(result.NotPlacedRects) != 0 || packer.Occupancy() != 1 {
+			t.Errorf("TestGuillotineBinPackerPerfectFit split:%d failed: occupancy %f", split, packer.Occupancy())
+		}
+	}
+}
+
+func TestSkylineBinPackerRows(t *testing.T) {
+	// Rects with the same height are placed in rows, from the bottom up
+	packer := NewSkylineBinPacker(30, 30, 0, 0, false, SkylineBottomLeft)
+	for i := 0; i < 9; i++ {
+		node, ok := packer.Insert(10, 10)
+		if !ok || node.X != (i%3)*10 || node.Y != (i/3)*10 {
+			t.Errorf("TestSkylineBinPackerRows #%d failed: %v placed:%v", i, node.Rect, ok)
+		}
+	}
+	_, ok := packer.Insert(1, 1)
+	if ok {
+		t.Errorf("TestSkylineBinPackerRows failed: the bin should be full")
+	}
+}
+
+// === Benchmarks
+
+func benchmarkBinPacker(b *testing.B, factory binPackerFactory) {
+	rects := setupRectNodes(200, 8, 64)
+	input := make([]RectNode, len(rects))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(input, rects)
+		factory.newPacker(1024, 1024, true).Pack(input)
+	}
+}
+
+// goos: linux
+// goarch: amd64
+// pkg: github.com/maxfish/go-libs/pkg/geom
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkBinPackers/MaxRects-BSSF         	      75	  14368717 ns/op
+// BenchmarkBinPackers/MaxRects-CP           	       4	 274491372 ns/op
+// BenchmarkBinPackers/Skyline-BL            	     174	   7193874 ns/op
+// BenchmarkBinPackers/Skyline-MW            	      38	  32023953 ns/op
+// BenchmarkBinPackers/Guillotine-BAF-SLAS   	      61	  19314644 ns/op
+// BenchmarkBinPackers/Guillotine-BSSF-MINAS 	      49	  20562490 ns/op
+func BenchmarkBinPackers(b *testing.B) {
+	for _, factory := range binPackerFactories {
+		b.Run(factory.name, func(b *testing.B) {
+			benchmarkBinPacker(b, factory)
+		})
+	}
+}
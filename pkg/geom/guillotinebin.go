@@ -0,0 +1,273 @@
+package geom
+
+// Ported to Go from the C++ implementation made by Jukka Jylänki (https://github.com/juj/RectangleBinPack/)
+
+import (
+	"github.com/maxfish/go-libs/pkg/imath"
+	"math"
+)
+
+type GuillotineSplitHeuristic int
+
+const (
+	// SLAS: Splits along the axis which leaves the shorter leftover side.
+	SplitShorterLeftoverAxis GuillotineSplitHeuristic = iota
+	// LLAS: Splits along the axis which leaves the longer leftover side.
+	SplitLongerLeftoverAxis
+	// MINAS: Splits so that the biggest of the two new free rects is as small as possible.
+	SplitMinimizeArea
+	// MAXAS: Splits so that the biggest of the two new free rects is as big as possible.
+	SplitMaximizeArea
+	// SAS: Splits along the shorter side of the free rect.
+	SplitShorterAxis
+	// LAS: Splits along the longer side of the free rect.
+	SplitLongerAxis
+)
+
+// GuillotineBinPacker splits the free area with straight cuts. It's a compromise between the speed of
+// SkylineBinPacker and the quality of MaxRectsBinPacker.
+type GuillotineBinPacker struct {
+	binWidth, binHeight int
+	paddingX, paddingY  int
+	allowRotation       bool
+	method              FreeRectChoiceHeuristic
+	splitMethod         GuillotineSplitHeuristic
+	merge               bool
+	usedRectangles      []RectNode
+	freeRectangles      []RectNode
+}
+
+// Create a new GuillotineBinPacker packer.
+// Only RectBestShortSideFit, RectBestAreaFit and RectBestLongSideFit are supported as heuristics.
+// When merge is true the adjacent free rects are joined, when possible, after each placement.
+func NewGuillotineBinPacker(width, height int, paddingX, paddingY int, allowRotation bool, method FreeRectChoiceHeuristic, splitMethod GuillotineSplitHeuristic, merge bool) *GuillotineBinPacker {
+	return &GuillotineBinPacker{
+		binWidth:       width,
+		binHeight:      height,
+		paddingX:       paddingX,
+		paddingY:       paddingY,
+		allowRotation:  allowRotation,
+		method:         method,
+		splitMethod:    splitMethod,
+		merge:          merge,
+		usedRectangles: make([]RectNode, 0),
+		freeRectangles: []RectNode{NewRectNode(-1, width, height)},
+	}
+}
+
+// Packs the passed rects, at each step choosing the pair of rect and free rect which fit best.
+// The dimensions of the returned rects will include the padding.
+func (gb *GuillotineBinPacker) Pack(inputRects []RectNode) *BinPackResult {
+	rects := inputRects
+	for len(rects) > 0 {
+		bestFreeRectIndex := -1
+		bestRectIndex := -1
+		var bestNode RectNode
+		bestScore := math.MaxInt32
+
+		for i := 0; i < len(gb.freeRectangles); i++ {
+			for j := 0; j < len(rects); j++ {
+				var score int
+				newNode := gb.scoreRect(gb.freeRectangles[i], rects[j], &score)
+				if newNode.H != 0 && score < bestScore {
+					bestScore = score
+					bestNode = newNode
+					bestNode.Index = rects[j].Index
+					bestFreeRectIndex = i
+					bestRectIndex = j
+				}
+				if bestScore == math.MinInt32 {
+					break
+				}
+			}
+			// A perfect fit has been found, there's no need to look further
+			if bestScore == math.MinInt32 {
+				break
+			}
+		}
+
+		if bestRectIndex == -1 {
+			break
+		}
+
+		gb.placeRect(bestFreeRectIndex, bestNode)
+		rects = append(rects[:bestRectIndex], rects[bestRectIndex+1:]...)
+	}
+
+	return newBinPackResult(gb.usedRectangles, rects)
+}
+
+// Insert places a single rect of the given size. It returns false if the rect doesn't fit.
+// The dimensions of the returned rect will include the padding. The Index of the returned rect is -1.
+func (gb *GuillotineBinPacker) Insert(width, height int) (RectNode, bool) {
+	bestFreeRectIndex := -1
+	var bestNode RectNode
+	bestScore := math.MaxInt32
+
+	rect := NewRectNode(-1, width, height)
+	for i := 0; i < len(gb.freeRectangles); i++ {
+		var score int
+		newNode := gb.scoreRect(gb.freeRectangles[i], rect, &score)
+		if newNode.H != 0 && score < bestScore {
+			bestScore = score
+			bestNode = newNode
+			bestFreeRectIndex = i
+		}
+		// A perfect fit has been found, there's no need to look further
+		if bestScore == math.MinInt32 {
+			break
+		}
+	}
+
+	if bestFreeRectIndex == -1 {
+		return RectNode{}, false
+	}
+	bestNode.Index = -1
+
+	gb.placeRect(bestFreeRectIndex, bestNode)
+	return bestNode, true
+}
+
+// Computes the ratio of used surface area
+func (gb *GuillotineBinPacker) Occupancy() float32 {
+	return occupancy(gb.usedRectangles, gb.binWidth, gb.binHeight)
+}
+
+// scoreRect computes where the rect would be placed inside the free rect, and how good the fit is.
+// A perfect fit gets math.MinInt32 as score. The returned node has H == 0 if the rect doesn't fit.
+func (gb *GuillotineBinPacker) scoreRect(freeRect, rect RectNode, score *int) RectNode {
+	width := rect.W + gb.paddingX
+	height := rect.H + gb.paddingY
+	rotatedWidth := rect.H + gb.paddingX
+	rotatedHeight := rect.W + gb.paddingY
+
+	newNode := RectNode{Rect: Rect{X: freeRect.X, Y: freeRect.Y}}
+	*score = math.MaxInt32
+	if freeRect.W == width && freeRect.H == height {
+		newNode.W, newNode.H = width, height
+		*score = math.MinInt32
+	} else if gb.allowRotation && freeRect.W == rotatedWidth && freeRect.H == rotatedHeight {
+		newNode.W, newNode.H = rotatedWidth, rotatedHeight
+		newNode.Rotated = true
+		*score = math.MinInt32
+	} else if freeRect.W >= width && freeRect.H >= height {
+		newNode.W, newNode.H = width, height
+		*score = gb.scoreByHeuristic(width, height, freeRect)
+	} else if gb.allowRotation && freeRect.W >= rotatedWidth && freeRect.H >= rotatedHeight {
+		newNode.W, newNode.H = rotatedWidth, rotatedHeight
+		newNode.Rotated = true
+		*score = gb.scoreByHeuristic(rotatedWidth, rotatedHeight, freeRect)
+	}
+	return newNode
+}
+
+func (gb *GuillotineBinPacker) scoreByHeuristic(width, height int, freeRect RectNode) int {
+	leftoverH := imath.Abs(freeRect.W - width)
+	leftoverV := imath.Abs(freeRect.H - height)
+	switch gb.method {
+	case RectBestAreaFit:
+		return freeRect.W*freeRect.H - width*height
+	case RectBestShortSideFit:
+		return imath.Min(leftoverH, leftoverV)
+	case RectBestLongSideFit:
+		return imath.Max(leftoverH, leftoverV)
+	default:
+		panic("Unsupported free-rect choice heuristic")
+	}
+}
+
+func (gb *GuillotineBinPacker) placeRect(freeRectIndex int, node RectNode) {
+	freeRect := gb.freeRectangles[freeRectIndex]
+	gb.freeRectangles = append(gb.freeRectangles[:freeRectIndex], gb.freeRectangles[freeRectIndex+1:]...)
+	gb.splitFreeRectByHeuristic(freeRect, node)
+	if gb.merge {
+		gb.mergeFreeList()
+	}
+	gb.usedRectangles = append(gb.usedRectangles, node)
+}
+
+// splitFreeRectByHeuristic chooses the axis along which the free rect is cut, after placing the node in it
+func (gb *GuillotineBinPacker) splitFreeRectByHeuristic(freeRect, placedRect RectNode) {
+	// Dimensions of the leftover area
+	w := freeRect.W - placedRect.W
+	h := freeRect.H - placedRect.H
+
+	var splitHorizontal bool
+	switch gb.splitMethod {
+	case SplitShorterLeftoverAxis:
+		splitHorizontal = w <= h
+	case SplitLongerLeftoverAxis:
+		splitHorizontal = w > h
+	case SplitMinimizeArea:
+		splitHorizontal = placedRect.W*h > w*placedRect.H
+	case SplitMaximizeArea:
+		splitHorizontal = placedRect.W*h <= w*placedRect.H
+	case SplitShorterAxis:
+		splitHorizontal = freeRect.W <= freeRect.H
+	case SplitLongerAxis:
+		splitHorizontal = freeRect.W > freeRect.H
+	default:
+		panic("Unknown guillotine split heuristic")
+	}
+	gb.splitFreeRectAlongAxis(freeRect, placedRect, splitHorizontal)
+}
+
+// splitFreeRectAlongAxis adds to the free list the two rects left at the bottom and at the right of the placed rect
+func (gb *GuillotineBinPacker) splitFreeRectAlongAxis(freeRect, placedRect RectNode, splitHorizontal bool) {
+	bottom := NewRectNode(-1, 0, freeRect.H-placedRect.H)
+	bottom.X = freeRect.X
+	bottom.Y = freeRect.Y + placedRect.H
+
+	right := NewRectNode(-1, freeRect.W-placedRect.W, 0)
+	right.X = freeRect.X + placedRect.W
+	right.Y = freeRect.Y
+
+	if splitHorizontal {
+		bottom.W = freeRect.W
+		right.H = placedRect.H
+	} else {
+		bottom.W = placedRect.W
+		right.H = freeRect.H
+	}
+
+	if bottom.W > 0 && bottom.H > 0 {
+		gb.freeRectangles = append(gb.freeRectangles, bottom)
+	}
+	if right.W > 0 && right.H > 0 {
+		gb.freeRectangles = append(gb.freeRectangles, right)
+	}
+}
+
+// mergeFreeList joins the pairs of free rects which together form a rect
+func (gb *GuillotineBinPacker) mergeFreeList() {
+	for i := 0; i < len(gb.freeRectangles); i++ {
+		for j := i + 1; j < len(gb.freeRectangles); j++ {
+			a := &gb.freeRectangles[i]
+			b := gb.freeRectangles[j]
+			merged := false
+			if a.W == b.W && a.X == b.X {
+				if a.Y == b.Bottom() {
+					a.Y -= b.H
+					a.H += b.H
+					merged = true
+				} else if a.Bottom() == b.Y {
+					a.H += b.H
+					merged = true
+				}
+			} else if a.H == b.H && a.Y == b.Y {
+				if a.X == b.Right() {
+					a.X -= b.W
+					a.W += b.W
+					merged = true
+				} else if a.Right() == b.X {
+					a.W += b.W
+					merged = true
+				}
+			}
+			if merged {
+				gb.freeRectangles = append(gb.freeRectangles[:j], gb.freeRectangles[j+1:]...)
+				j--
+			}
+		}
+	}
+}
@@ -45,9 +45,9 @@ const (
 	// BL: Does the Tetris placement.
 	RectBottomLeftRule
 	// BLSF: Positions the rectangle against the long side of a free rectangle into which it fits the best.
-	// RectBestLongSideFit
+	RectBestLongSideFit
 	// CP: Chooses the placement where the rectangle touches other rects as much as possible.
-	// RectContactPointRule
+	RectContactPointRule
 )
 
 type MaxRectsBinPacker struct {
@@ -105,21 +105,62 @@ func (mr *MaxRectsBinPacker) Pack(inputRects []RectNode, method FreeRectChoiceHe
 		rects = append(rects[:bestRectIndex], rects[bestRectIndex+1:]...)
 	}
 
-	// Put the result together
-	result := &MaxRectsBinResult{
-		PlacedRects:    mr.usedRectangles,
-		NotPlacedRects: rects,
+	result := newBinPackResult(mr.usedRectangles, rects)
+	result.Method = method
+	return result
+}
+
+// Insert places a single rect of the given size with the chosen heuristic. It returns false if the rect doesn't fit.
+// The dimensions of the returned rect will include the padding. The Index of the returned rect is -1.
+func (mr *MaxRectsBinPacker) Insert(width, height int, method FreeRectChoiceHeuristic) (RectNode, bool) {
+	var score1, score2 int
+	newNode := mr.scoreRect(NewRectNode(-1, width, height), method, &score1, &score2)
+	if newNode.H == 0 {
+		return RectNode{}, false
 	}
-	// Compute the total area used by the rects
+	newNode.Index = -1
+
+	mr.placeRect(newNode)
+	return newNode, true
+}
+
+// Free releases the area of a rect previously returned by Insert or Pack, so that it can be reused.
+// It returns false if the rect isn't currently placed in the bin.
+func (mr *MaxRectsBinPacker) Free(node RectNode) bool {
+	index := -1
 	for i := 0; i < len(mr.usedRectangles); i++ {
-		rect := mr.usedRectangles[i]
-		result.Width = imath.Max(result.Width, rect.Right())
-		result.Height = imath.Max(result.Height, rect.Bottom())
+		if mr.usedRectangles[i].Rect.EqualsTo(node.Rect) {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return false
+	}
+
+	// A new slice is created to avoid modifying the PlacedRects of a previous result
+	usedRectangles := make([]RectNode, 0, len(mr.usedRectangles)-1)
+	usedRectangles = append(usedRectangles, mr.usedRectangles[:index]...)
+	mr.usedRectangles = append(usedRectangles, mr.usedRectangles[index+1:]...)
+	mr.rebuildFreeList()
+	return true
+}
+
+// rebuildFreeList computes the maximal free rects from scratch, splitting the whole bin with the used rects
+func (mr *MaxRectsBinPacker) rebuildFreeList() {
+	mr.freeRectangles = []RectNode{NewRectNode(-1, mr.binWidth, mr.binHeight)}
+	for _, node := range mr.usedRectangles {
+		mr.splitFreeList(node)
 	}
-	return result
 }
 
 func (mr *MaxRectsBinPacker) placeRect(node RectNode) {
+	mr.splitFreeList(node)
+	mr.usedRectangles = append(mr.usedRectangles, node)
+}
+
+// splitFreeList removes the area of the node from the free rects
+func (mr *MaxRectsBinPacker) splitFreeList(node RectNode) {
 	numRectanglesToProcess := len(mr.freeRectangles)
 	for i := 0; i < numRectanglesToProcess; i++ {
 		if mr.splitFreeNode(mr.freeRectangles[i], node) {
@@ -130,7 +171,6 @@ func (mr *MaxRectsBinPacker) placeRect(node RectNode) {
 	}
 
 	mr.pruneFreeList()
-	mr.usedRectangles = append(mr.usedRectangles, node)
 }
 
 func (mr *MaxRectsBinPacker) scoreRect(rect RectNode, method FreeRectChoiceHeuristic, score1, score2 *int) RectNode {
@@ -152,6 +192,14 @@ func (mr *MaxRectsBinPacker) scoreRect(rect RectNode, method FreeRectChoiceHeuri
 	case RectBestAreaFit:
 		newNode = mr.findPositionForNewNodeBestAreaFit(width, height, rotatedWidth, rotatedHeight, mr.allowRotation, score1, score2)
 		break
+	case RectBestLongSideFit:
+		newNode = mr.findPositionForNewNodeBestLongSideFit(width, height, rotatedWidth, rotatedHeight, mr.allowRotation, score2, score1)
+		break
+	case RectContactPointRule:
+		newNode = mr.findPositionForNewNodeContactPoint(width, height, rotatedWidth, rotatedHeight, mr.allowRotation, score1)
+		// Reverse since we are minimizing, but for contact point score bigger is better
+		*score1 = -*score1
+		break
 	default:
 		panic("Unknown free-rect choice heuristic")
 	}
@@ -167,11 +215,7 @@ func (mr *MaxRectsBinPacker) scoreRect(rect RectNode, method FreeRectChoiceHeuri
 
 // Computes the ratio of used surface area
 func (mr *MaxRectsBinPacker) Occupancy() float32 {
-	usedSurfaceArea := 0
-	for i := 0; i < len(mr.usedRectangles); i++ {
-		usedSurfaceArea += mr.usedRectangles[i].W * mr.usedRectangles[i].H
-	}
-	return float32(usedSurfaceArea) / float32(mr.binWidth*mr.binHeight)
+	return occupancy(mr.usedRectangles, mr.binWidth, mr.binHeight)
 }
 
 func (mr *MaxRectsBinPacker) findPositionForNewNodeBestAreaFit(width, height, rotatedWidth, rotatedHeight int, rotate bool, bestAreaFit, bestShortSideFit *int) RectNode {
@@ -263,6 +307,50 @@ func (mr *MaxRectsBinPacker) findPositionForNewNodeBestShortSideFit(width, heigh
 	return bestNode
 }
 
+func (mr *MaxRectsBinPacker) findPositionForNewNodeBestLongSideFit(width, height, rotatedWidth, rotatedHeight int, rotate bool, bestShortSideFit, bestLongSideFit *int) RectNode {
+	bestNode := RectNode{}
+	*bestShortSideFit = math.MaxInt32
+	*bestLongSideFit = math.MaxInt32
+
+	for i := 0; i < len(mr.freeRectangles); i++ {
+		// Try to place the rectangle in upright (non-rotated) orientation
+		if mr.freeRectangles[i].W >= width && mr.freeRectangles[i].H >= height {
+			leftoverH := imath.Abs(mr.freeRectangles[i].W - width)
+			leftoverV := imath.Abs(mr.freeRectangles[i].H - height)
+			shortSideFit := imath.Min(leftoverH, leftoverV)
+			longSideFit := imath.Max(leftoverH, leftoverV)
+
+			if longSideFit < *bestLongSideFit || (longSideFit == *bestLongSideFit && shortSideFit < *bestShortSideFit) {
+				bestNode.X = mr.freeRectangles[i].X
+				bestNode.Y = mr.freeRectangles[i].Y
+				bestNode.W = width
+				bestNode.H = height
+				*bestShortSideFit = shortSideFit
+				*bestLongSideFit = longSideFit
+				bestNode.Rotated = false
+			}
+		}
+
+		if rotate && mr.freeRectangles[i].W >= rotatedWidth && mr.freeRectangles[i].H >= rotatedHeight {
+			leftoverH := imath.Abs(mr.freeRectangles[i].W - rotatedWidth)
+			leftoverV := imath.Abs(mr.freeRectangles[i].H - rotatedHeight)
+			shortSideFit := imath.Min(leftoverH, leftoverV)
+			longSideFit := imath.Max(leftoverH, leftoverV)
+
+			if longSideFit < *bestLongSideFit || (longSideFit == *bestLongSideFit && shortSideFit < *bestShortSideFit) {
+				bestNode.X = mr.freeRectangles[i].X
+				bestNode.Y = mr.freeRectangles[i].Y
+				bestNode.W = rotatedWidth
+				bestNode.H = rotatedHeight
+				*bestShortSideFit = shortSideFit
+				*bestLongSideFit = longSideFit
+				bestNode.Rotated = true
+			}
+		}
+	}
+	return bestNode
+}
+
 func (mr *MaxRectsBinPacker) findPositionForNewNodeBottomLeft(width, height, rotatedWidth, rotatedHeight int, rotate bool, bestY, bestX *int) RectNode {
 	bestNode := RectNode{}
 	*bestX = math.MaxInt32
@@ -298,6 +386,68 @@ func (mr *MaxRectsBinPacker) findPositionForNewNodeBottomLeft(width, height, rot
 	return bestNode
 }
 
+func (mr *MaxRectsBinPacker) findPositionForNewNodeContactPoint(width, height, rotatedWidth, rotatedHeight int, rotate bool, bestContactScore *int) RectNode {
+	bestNode := RectNode{}
+	*bestContactScore = -1
+
+	for i := 0; i < len(mr.freeRectangles); i++ {
+		// Try to place the rectangle in upright (non-rotated) orientation
+		if mr.freeRectangles[i].W >= width && mr.freeRectangles[i].H >= height {
+			score := mr.contactPointScoreNode(mr.freeRectangles[i].X, mr.freeRectangles[i].Y, width, height)
+			if score > *bestContactScore {
+				bestNode.X = mr.freeRectangles[i].X
+				bestNode.Y = mr.freeRectangles[i].Y
+				bestNode.W = width
+				bestNode.H = height
+				*bestContactScore = score
+				bestNode.Rotated = false
+			}
+		}
+		if rotate && mr.freeRectangles[i].W >= rotatedWidth && mr.freeRectangles[i].H >= rotatedHeight {
+			score := mr.contactPointScoreNode(mr.freeRectangles[i].X, mr.freeRectangles[i].Y, rotatedWidth, rotatedHeight)
+			if score > *bestContactScore {
+				bestNode.X = mr.freeRectangles[i].X
+				bestNode.Y = mr.freeRectangles[i].Y
+				bestNode.W = rotatedWidth
+				bestNode.H = rotatedHeight
+				*bestContactScore = score
+				bestNode.Rotated = true
+			}
+		}
+	}
+	return bestNode
+}
+
+// contactPointScoreNode computes how much of the perimeter of the node touches the bin borders or the used rects
+func (mr *MaxRectsBinPacker) contactPointScoreNode(x, y, width, height int) int {
+	score := 0
+	if x == 0 || x+width == mr.binWidth {
+		score += height
+	}
+	if y == 0 || y+height == mr.binHeight {
+		score += width
+	}
+
+	for i := 0; i < len(mr.usedRectangles); i++ {
+		used := mr.usedRectangles[i]
+		if used.X == x+width || used.Right() == x {
+			score += commonIntervalLength(used.Y, used.Bottom(), y, y+height)
+		}
+		if used.Y == y+height || used.Bottom() == y {
+			score += commonIntervalLength(used.X, used.Right(), x, x+width)
+		}
+	}
+	return score
+}
+
+// commonIntervalLength returns 0 if the two intervals i1 and i2 are disjoint, or the length of their overlap otherwise
+func commonIntervalLength(i1Start, i1End, i2Start, i2End int) int {
+	if i1End < i2Start || i2End < i1Start {
+		return 0
+	}
+	return imath.Min(i1End, i2End) - imath.Max(i1Start, i2Start)
+}
+
 func (mr *MaxRectsBinPacker) splitFreeNode(freeNode, usedNode RectNode) bool {
 	if !usedNode.Rect.Intersect(freeNode.Rect) {
 		return false
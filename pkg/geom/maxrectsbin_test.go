@@ -0,0 +1,136 @@
+package geom
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/rand"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"testing"
+)
+
+var allHeuristics = []FreeRectChoiceHeuristic{
+	RectBestShortSideFit,
+	RectBestAreaFit,
+	RectBottomLeftRule,
+	RectBestLongSideFit,
+	RectContactPointRule,
+}
+
+func setupRectNodes(n, minSize, maxSize int) []RectNode {
+	// Prepare random, but predictable, sizes
+	rng := rand.NewHashRngWithSeed(0)
+	rects := make([]RectNode, n)
+	for i := 0; i < n; i++ {
+		w := int(rng.NextUint32InRange(minSize, maxSize))
+		h := int(rng.NextUint32InRange(minSize, maxSize))
+		rects[i] = NewRectNode(i, w, h)
+	}
+	return rects
+}
+
+func assertValidPacking(t *testing.T, text string, binWidth, binHeight int, placed []RectNode) {
+	bin := Rect{W: binWidth, H: binHeight}
+	for i, a := range placed {
+		if !a.IsContainedIn(bin) {
+			t.Errorf("%s failed: rect %v is outside the bin %v", text, a.Rect, bin)
+		}
+		for _, b := range placed[i+1:] {
+			if a.Intersect(b.Rect) {
+				t.Errorf("%s failed: rect %v overlaps %v", text, a.Rect, b.Rect)
+			}
+		}
+	}
+}
+
+func TestMaxRectsBinPackerHeuristics(t *testing.T) {
+	const binSize = 256
+	for _, allowRotation := range []bool{false, true} {
+		for _, method := range allHeuristics {
+			rects := setupRectNodes(60, 8, 40)
+			packer := NewMaxRectsBinPacker(binSize, binSize, 0, 0, allowRotation)
+			result := packer.Pack(rects, method)
+			errorText := fmt.Sprintf("TestMaxRectsBinPackerHeuristics method:%d rotation:%v", method, allowRotation)
+			assertValidPacking(t, errorText, binSize, binSize, result.PlacedRects)
+			if len(result.PlacedRects)+len(result.NotPlacedRects) != 60 {
+				t.Errorf("%s failed: %d placed + %d not placed, expecting 60", errorText,
+					len(result.PlacedRects), len(result.NotPlacedRects))
+			}
+		}
+	}
+}
+
+func TestMaxRectsBinPackerOccupancy(t *testing.T) {
+	// More rects than the bin can hold, so the heuristics end up with different occupancies
+	const binSize = 256
+	for _, allowRotation := range []bool{false, true} {
+		occupancy := make(map[FreeRectChoiceHeuristic]float32)
+		for _, method := range allHeuristics {
+			packer := NewMaxRectsBinPacker(binSize, binSize, 0, 0, allowRotation)
+			packer.Pack(setupRectNodes(120, 8, 40), method)
+			occupancy[method] = packer.Occupancy()
+		}
+
+		worst, best := occupancy[RectBestShortSideFit], occupancy[RectBestShortSideFit]
+		for _, method := range []FreeRectChoiceHeuristic{RectBestAreaFit, RectBottomLeftRule} {
+			if occupancy[method] < worst {
+				worst = occupancy[method]
+			}
+			if occupancy[method] > best {
+				best = occupancy[method]
+			}
+		}
+
+		errorText := fmt.Sprintf("TestMaxRectsBinPackerOccupancy rotation:%v", allowRotation)
+		if occupancy[RectBestLongSideFit] < worst {
+			t.Errorf("%s failed: BLSF occupancy %f is lower than the worst of BSSF/BAF/BL %f", errorText, occupancy[RectBestLongSideFit], worst)
+		}
+		if occupancy[RectContactPointRule] < best {
+			t.Errorf("%s failed: CP occupancy %f is lower than the best of BSSF/BAF/BL %f", errorText, occupancy[RectContactPointRule], best)
+		}
+	}
+}
+
+func TestMaxRectsBinPackerContactPoint(t *testing.T) {
+	// Two 2x1 rects in a 2x2 bin: contact point has to stack them, touching the borders and each other
+	packer := NewMaxRectsBinPacker(2, 2, 0, 0, false)
+	result := packer.Pack([]RectNode{NewRectNode(0, 2, 1), NewRectNode(1, 2, 1)}, RectContactPointRule)
+	if len(result.NotPlacedRects) != 0 || packer.Occupancy() != 1 {
+		t.Errorf("TestMaxRectsBinPackerContactPoint failed: occupancy %f", packer.Occupancy())
+	}
+}
+
+func TestMaxRectsBinPackerInsertAndFree(t *testing.T) {
+	const binSize = 128
+	packer := NewMaxRectsBinPacker(binSize, binSize, 0, 0, false)
+
+	// Fill the bin with 16x16 rects
+	placed := make([]RectNode, 0)
+	for {
+		node, ok := packer.Insert(16, 16, RectContactPointRule)
+		if !ok {
+			break
+		}
+		placed = append(placed, node)
+	}
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree count", 64, len(placed))
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree full occupancy", float32(1), packer.Occupancy())
+
+	// Free two adjacent rects, then a 32x16 rect has to fit exactly in their place
+	a, b := placed[0], placed[0]
+	for _, node := range placed {
+		if node.X == a.Right() && node.Y == a.Y {
+			b = node
+		}
+	}
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree free a", true, packer.Free(a))
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree free b", true, packer.Free(b))
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree free twice", false, packer.Free(b))
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree occupancy", float32(62)/64, packer.Occupancy())
+
+	node, ok := packer.Insert(32, 16, RectBestShortSideFit)
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree reinsert", true, ok)
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree reinsert rect", Rect{X: a.X, Y: a.Y, W: 32, H: 16}, node.Rect)
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree refilled occupancy", float32(1), packer.Occupancy())
+
+	_, ok = packer.Insert(1, 1, RectBestShortSideFit)
+	testx.AssertEqual(t, "TestMaxRectsBinPackerInsertAndFree overflow", false, ok)
+}
@@ -0,0 +1,274 @@
+package geom
+
+// Ported to Go from the C++ implementation made by Jukka Jylänki (https://github.com/juj/RectangleBinPack/)
+
+import (
+	"github.com/maxfish/go-libs/pkg/imath"
+	"math"
+)
+
+type SkylineHeuristic int
+
+const (
+	// BL: Places the rect so that its top side is as low as possible.
+	SkylineBottomLeft SkylineHeuristic = iota
+	// MW: Places the rect where it leaves the least amount of wasted area below it.
+	SkylineMinWasteFit
+)
+
+// skylineNode is a horizontal segment of the skyline, at height Y
+type skylineNode struct {
+	X, Y, W int
+}
+
+// SkylineBinPacker is fast, and well suited for packing rects as they come (e.g. a glyph cache), but it wastes
+// more space than MaxRectsBinPacker.
+type SkylineBinPacker struct {
+	binWidth, binHeight int
+	paddingX, paddingY  int
+	allowRotation       bool
+	method              SkylineHeuristic
+	usedRectangles      []RectNode
+	skyline             []skylineNode
+}
+
+// Create a new SkylineBinPacker packer
+func NewSkylineBinPacker(width, height int, paddingX, paddingY int, allowRotation bool, method SkylineHeuristic) *SkylineBinPacker {
+	return &SkylineBinPacker{
+		binWidth:       width,
+		binHeight:      height,
+		paddingX:       paddingX,
+		paddingY:       paddingY,
+		allowRotation:  allowRotation,
+		method:         method,
+		usedRectangles: make([]RectNode, 0),
+		skyline:        []skylineNode{{X: 0, Y: 0, W: width}},
+	}
+}
+
+// Packs the passed rects, at each step choosing the one which fits best.
+// The dimensions of the returned rects will include the padding.
+func (sb *SkylineBinPacker) Pack(inputRects []RectNode) *BinPackResult {
+	rects := inputRects
+	for len(rects) > 0 {
+		bestRectIndex := -1
+		bestSkylineIndex := -1
+		var bestNode RectNode
+		bestScore1 := math.MaxInt32
+		bestScore2 := math.MaxInt32
+
+		for i := 0; i < len(rects); i++ {
+			var score1, score2, skylineIndex int
+			newNode := sb.scoreRect(rects[i], &score1, &score2, &skylineIndex)
+			if newNode.H != 0 && (score1 < bestScore1 || (score1 == bestScore1 && score2 < bestScore2)) {
+				bestScore1 = score1
+				bestScore2 = score2
+				bestNode = newNode
+				bestNode.Index = rects[i].Index
+				bestSkylineIndex = skylineIndex
+				bestRectIndex = i
+			}
+		}
+
+		if bestRectIndex == -1 {
+			break
+		}
+
+		sb.placeRect(bestSkylineIndex, bestNode)
+		rects = append(rects[:bestRectIndex], rects[bestRectIndex+1:]...)
+	}
+
+	return newBinPackResult(sb.usedRectangles, rects)
+}
+
+// Insert places a single rect of the given size. It returns false if the rect doesn't fit.
+// The dimensions of the returned rect will include the padding. The Index of the returned rect is -1.
+func (sb *SkylineBinPacker) Insert(width, height int) (RectNode, bool) {
+	var score1, score2, skylineIndex int
+	newNode := sb.scoreRect(NewRectNode(-1, width, height), &score1, &score2, &skylineIndex)
+	if newNode.H == 0 {
+		return RectNode{}, false
+	}
+	newNode.Index = -1
+
+	sb.placeRect(skylineIndex, newNode)
+	return newNode, true
+}
+
+// Computes the ratio of used surface area
+func (sb *SkylineBinPacker) Occupancy() float32 {
+	return occupancy(sb.usedRectangles, sb.binWidth, sb.binHeight)
+}
+
+func (sb *SkylineBinPacker) scoreRect(rect RectNode, score1, score2, skylineIndex *int) RectNode {
+	width := rect.W + sb.paddingX
+	height := rect.H + sb.paddingY
+	rotatedWidth := rect.H + sb.paddingX
+	rotatedHeight := rect.W + sb.paddingY
+
+	switch sb.method {
+	case SkylineBottomLeft:
+		return sb.findPositionForNewNodeBottomLeft(width, height, rotatedWidth, rotatedHeight, score1, score2, skylineIndex)
+	case SkylineMinWasteFit:
+		return sb.findPositionForNewNodeMinWaste(width, height, rotatedWidth, rotatedHeight, score2, score1, skylineIndex)
+	default:
+		panic("Unknown skyline heuristic")
+	}
+}
+
+func (sb *SkylineBinPacker) placeRect(skylineIndex int, node RectNode) {
+	sb.addSkylineLevel(skylineIndex, node)
+	sb.usedRectangles = append(sb.usedRectangles, node)
+}
+
+// rectFits checks if a rect fits on top of the skyline, starting at the given node.
+// It returns the Y at which the rect would be placed.
+func (sb *SkylineBinPacker) rectFits(skylineIndex, width, height int) (int, bool) {
+	x := sb.skyline[skylineIndex].X
+	if x+width > sb.binWidth {
+		return 0, false
+	}
+	widthLeft := width
+	i := skylineIndex
+	y := sb.skyline[skylineIndex].Y
+	for widthLeft > 0 {
+		y = imath.Max(y, sb.skyline[i].Y)
+		if y+height > sb.binHeight {
+			return 0, false
+		}
+		widthLeft -= sb.skyline[i].W
+		i++
+	}
+	return y, true
+}
+
+// computeWastedArea computes the area left unused below a rect placed at the given node and height
+func (sb *SkylineBinPacker) computeWastedArea(skylineIndex, width, y int) int {
+	wastedArea := 0
+	rectLeft := sb.skyline[skylineIndex].X
+	rectRight := rectLeft + width
+	for ; skylineIndex < len(sb.skyline) && sb.skyline[skylineIndex].X < rectRight; skylineIndex++ {
+		node := sb.skyline[skylineIndex]
+		if node.X >= rectRight || node.X+node.W <= rectLeft {
+			break
+		}
+		leftSide := node.X
+		rightSide := imath.Min(rectRight, leftSide+node.W)
+		wastedArea += (rightSide - leftSide) * (y - node.Y)
+	}
+	return wastedArea
+}
+
+func (sb *SkylineBinPacker) findPositionForNewNodeBottomLeft(width, height, rotatedWidth, rotatedHeight int, bestHeight, bestWidth, bestIndex *int) RectNode {
+	bestNode := RectNode{}
+	*bestHeight = math.MaxInt32
+	*bestWidth = math.MaxInt32
+	*bestIndex = -1
+
+	for i := 0; i < len(sb.skyline); i++ {
+		// Try to place the rectangle in upright (non-rotated) orientation
+		if y, fits := sb.rectFits(i, width, height); fits {
+			if y+height < *bestHeight || (y+height == *bestHeight && sb.skyline[i].W < *bestWidth) {
+				bestNode.X = sb.skyline[i].X
+				bestNode.Y = y
+				bestNode.W = width
+				bestNode.H = height
+				*bestHeight = y + height
+				*bestWidth = sb.skyline[i].W
+				*bestIndex = i
+				bestNode.Rotated = false
+			}
+		}
+		if sb.allowRotation {
+			if y, fits := sb.rectFits(i, rotatedWidth, rotatedHeight); fits {
+				if y+rotatedHeight < *bestHeight || (y+rotatedHeight == *bestHeight && sb.skyline[i].W < *bestWidth) {
+					bestNode.X = sb.skyline[i].X
+					bestNode.Y = y
+					bestNode.W = rotatedWidth
+					bestNode.H = rotatedHeight
+					*bestHeight = y + rotatedHeight
+					*bestWidth = sb.skyline[i].W
+					*bestIndex = i
+					bestNode.Rotated = true
+				}
+			}
+		}
+	}
+	return bestNode
+}
+
+func (sb *SkylineBinPacker) findPositionForNewNodeMinWaste(width, height, rotatedWidth, rotatedHeight int, bestHeight, bestWastedArea, bestIndex *int) RectNode {
+	bestNode := RectNode{}
+	*bestHeight = math.MaxInt32
+	*bestWastedArea = math.MaxInt32
+	*bestIndex = -1
+
+	for i := 0; i < len(sb.skyline); i++ {
+		// Try to place the rectangle in upright (non-rotated) orientation
+		if y, fits := sb.rectFits(i, width, height); fits {
+			wastedArea := sb.computeWastedArea(i, width, y)
+			if wastedArea < *bestWastedArea || (wastedArea == *bestWastedArea && y+height < *bestHeight) {
+				bestNode.X = sb.skyline[i].X
+				bestNode.Y = y
+				bestNode.W = width
+				bestNode.H = height
+				*bestHeight = y + height
+				*bestWastedArea = wastedArea
+				*bestIndex = i
+				bestNode.Rotated = false
+			}
+		}
+		if sb.allowRotation {
+			if y, fits := sb.rectFits(i, rotatedWidth, rotatedHeight); fits {
+				wastedArea := sb.computeWastedArea(i, rotatedWidth, y)
+				if wastedArea < *bestWastedArea || (wastedArea == *bestWastedArea && y+rotatedHeight < *bestHeight) {
+					bestNode.X = sb.skyline[i].X
+					bestNode.Y = y
+					bestNode.W = rotatedWidth
+					bestNode.H = rotatedHeight
+					*bestHeight = y + rotatedHeight
+					*bestWastedArea = wastedArea
+					*bestIndex = i
+					bestNode.Rotated = true
+				}
+			}
+		}
+	}
+	return bestNode
+}
+
+// addSkylineLevel raises the skyline on top of the newly placed rect
+func (sb *SkylineBinPacker) addSkylineLevel(skylineIndex int, node RectNode) {
+	newNode := skylineNode{X: node.X, Y: node.Bottom(), W: node.W}
+	sb.skyline = append(sb.skyline, skylineNode{})
+	copy(sb.skyline[skylineIndex+1:], sb.skyline[skylineIndex:])
+	sb.skyline[skylineIndex] = newNode
+
+	// Shrink, or remove, the nodes now covered by the new one
+	for i := skylineIndex + 1; i < len(sb.skyline); i++ {
+		previous := sb.skyline[i-1]
+		if sb.skyline[i].X >= previous.X+previous.W {
+			break
+		}
+		shrink := previous.X + previous.W - sb.skyline[i].X
+		sb.skyline[i].X += shrink
+		sb.skyline[i].W -= shrink
+		if sb.skyline[i].W > 0 {
+			break
+		}
+		sb.skyline = append(sb.skyline[:i], sb.skyline[i+1:]...)
+		i--
+	}
+	sb.mergeSkylines()
+}
+
+// mergeSkylines joins the adjacent nodes at the same height
+func (sb *SkylineBinPacker) mergeSkylines() {
+	for i := 0; i < len(sb.skyline)-1; i++ {
+		if sb.skyline[i].Y == sb.skyline[i+1].Y {
+			sb.skyline[i].W += sb.skyline[i+1].W
+			sb.skyline = append(sb.skyline[:i+1], sb.skyline[i+2:]...)
+			i--
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package grid2d
+
+import (
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+)
+
+// RegionContours contains the closed polygons surrounding a region of solid cells, connected horizontally or vertically.
+// The last point of each polygon is connected to the first one.
+// With the Y axis pointing down, the Outer polygon is wound clockwise and the Holes counter-clockwise:
+// walking along any of them, the solid cells are always on the right side.
+type RegionContours struct {
+	Outer []geom.Point
+	Holes [][]geom.Point
+}
+
+// ComputeContours chains the edges computed by ComputeEdges into closed polygons, one set for each region of solid cells.
+// The regions are returned in the order in which their first cell is found, scanning the grid by rows.
+func ComputeContours(gridWidth, gridHeight int, isSolid ComputeEdgesCallback) []RegionContours {
+	segments := ComputeEdges(gridWidth, gridHeight, isSolid)
+	labels, regionInfos := LabelRegions(gridWidth, gridHeight, isSolid, false)
+	regions := make([]RegionContours, len(regionInfos))
+
+	segmentLabel := func(segment *geom.Segment) int {
+		cell := solidCellOfSegment(segment.A, segment.B)
+		label, _ := labels.Get(cell.X, cell.Y)
+		return label
+	}
+
+	for _, polygon := range chainSegments(segments, segmentLabel) {
+		label := segmentLabel(&geom.Segment{A: polygon[0], B: polygon[1]})
+		if polygonSignedArea(polygon) > 0 {
+			regions[label].Outer = polygon
+		} else {
+			regions[label].Holes = append(regions[label].Holes, polygon)
+		}
+	}
+	return regions
+}
+
+// chainSegments joins the segments into closed polygons, each segment has to start where another one ends.
+// Where two solid cells touch only at a corner, two segments start from the same point. If the cells belong to
+// different regions the chain turns right, following the same cell, otherwise it turns left: in both cases the
+// polygons never touch themselves.
+func chainSegments(segments []*geom.Segment, segmentLabel func(segment *geom.Segment) int) [][]geom.Point {
+	startingAt := make(map[geom.Point][]int)
+	for i, segment := range segments {
+		startingAt[segment.A] = append(startingAt[segment.A], i)
+	}
+
+	polygons := make([][]geom.Point, 0)
+	used := make([]bool, len(segments))
+	for first := range segments {
+		if used[first] {
+			continue
+		}
+		polygon := make([]geom.Point, 0)
+		current := first
+		for {
+			used[current] = true
+			polygon = append(polygon, segments[current].A)
+
+			// Chooses the segment of the same region which turns the most to the left,
+			// closing the polygon if it's the first one
+			next := -1
+			bestTurn := 2
+			label := segmentLabel(segments[current])
+			for _, candidate := range startingAt[segments[current].B] {
+				if used[candidate] && candidate != first {
+					continue
+				}
+				if segmentLabel(segments[candidate]) != label {
+					continue
+				}
+				turn := segmentsTurn(segments[current], segments[candidate])
+				if turn < bestTurn {
+					bestTurn = turn
+					next = candidate
+				}
+			}
+			if next == -1 || next == first {
+				break
+			}
+			current = next
+		}
+		polygons = append(polygons, polygon)
+	}
+	return polygons
+}
+
+// segmentsTurn returns 1 if going from the segment a to the segment b is a right turn (with the Y axis pointing down),
+// -1 if it's a left turn, and 0 if they have the same direction
+func segmentsTurn(a, b *geom.Segment) int {
+	aX, aY := imath.Sign(a.B.X-a.A.X), imath.Sign(a.B.Y-a.A.Y)
+	bX, bY := imath.Sign(b.B.X-b.A.X), imath.Sign(b.B.Y-b.A.Y)
+	return aX*bY - aY*bX
+}
+
+// solidCellOfSegment returns the cell on the right side of the first unit of the segment going from a to b
+func solidCellOfSegment(a, b geom.Point) geom.Point {
+	switch {
+	case b.X > a.X:
+		return geom.Point{X: a.X, Y: a.Y}
+	case b.Y > a.Y:
+		return geom.Point{X: a.X - 1, Y: a.Y}
+	case b.X < a.X:
+		return geom.Point{X: a.X - 1, Y: a.Y - 1}
+	default:
+		return geom.Point{X: a.X, Y: a.Y - 1}
+	}
+}
+
+// polygonSignedArea returns twice the area of the polygon, positive if the polygon is clockwise with the Y axis pointing down
+func polygonSignedArea(polygon []geom.Point) int {
+	area := 0
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area
+}
@@ -0,0 +1,87 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"testing"
+)
+
+// gridFromStrings describes a grid with a list of rows, where '#' marks the solid cells
+func gridFromStrings(rows []string) (int, int, ComputeEdgesCallback) {
+	return len(rows[0]), len(rows), func(x, y int) bool { return rows[y][x] == '#' }
+}
+
+func points(coords ...int) []geom.Point {
+	result := make([]geom.Point, 0, len(coords)/2)
+	for i := 0; i < len(coords); i += 2 {
+		result = append(result, geom.Point{X: coords[i], Y: coords[i+1]})
+	}
+	return result
+}
+
+func TestComputeContours(t *testing.T) {
+	var tests = []struct {
+		grid    []string
+		regions []RegionContours
+	}{
+		{[]string{"#"}, []RegionContours{
+			{Outer: points(0, 0, 1, 0, 1, 1, 0, 1)},
+		}},
+		// L shape
+		{[]string{"##.", "#..", "###"}, []RegionContours{
+			{Outer: points(0, 0, 2, 0, 2, 1, 1, 1, 1, 2, 3, 2, 3, 3, 0, 3)},
+		}},
+		// Cells touching at a corner are separate regions
+		{[]string{"#.", ".#"}, []RegionContours{
+			{Outer: points(0, 0, 1, 0, 1, 1, 0, 1)},
+			{Outer: points(1, 1, 2, 1, 2, 2, 1, 2)},
+		}},
+		// A region inside the hole of another one
+		{[]string{"#####", "#...#", "#.#.#", "#...#", "#####"}, []RegionContours{
+			{Outer: points(0, 0, 5, 0, 5, 5, 0, 5), Holes: [][]geom.Point{points(4, 1, 1, 1, 1, 4, 4, 4)}},
+			{Outer: points(2, 2, 3, 2, 3, 3, 2, 3)},
+		}},
+		// Holes touching at a corner are kept separated
+		{[]string{"####", "#.##", "##.#", "####"}, []RegionContours{
+			{Outer: points(0, 0, 4, 0, 4, 4, 0, 4), Holes: [][]geom.Point{
+				points(2, 1, 1, 1, 1, 2, 2, 2),
+				points(3, 2, 2, 2, 2, 3, 3, 3),
+			}},
+		}},
+	}
+
+	for i, test := range tests {
+		w, h, isSolid := gridFromStrings(test.grid)
+		regions := ComputeContours(w, h, isSolid)
+		testx.AssertEqual(t, fmt.Sprintf("TestComputeContours #%d", i), test.regions, regions)
+	}
+}
+
+func TestComputeContoursMatchesEdges(t *testing.T) {
+	// Every edge computed by ComputeEdges has to be used exactly once by the contours
+	grid := []string{
+		"##..####.#",
+		"#..#.#..##",
+		"#.###..#.#",
+		"..#.#.####",
+		"###.##...#",
+	}
+	w, h, isSolid := gridFromStrings(grid)
+	edgesLength := 0
+	for _, segment := range ComputeEdges(w, h, isSolid) {
+		edgesLength += imath.Abs(segment.B.X-segment.A.X) + imath.Abs(segment.B.Y-segment.A.Y)
+	}
+
+	contoursLength := 0
+	for _, region := range ComputeContours(w, h, isSolid) {
+		for _, polygon := range append([][]geom.Point{region.Outer}, region.Holes...) {
+			for i := range polygon {
+				a, b := polygon[i], polygon[(i+1)%len(polygon)]
+				contoursLength += imath.Abs(b.X-a.X) + imath.Abs(b.Y-a.Y)
+			}
+		}
+	}
+	testx.AssertEqual(t, "TestComputeContoursMatchesEdges", edgesLength, contoursLength)
+}
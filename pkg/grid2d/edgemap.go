@@ -0,0 +1,205 @@
+package grid2d
+
+import (
+	"github.com/maxfish/go-libs/pkg/geom"
+	"sort"
+)
+
+// EdgeMap keeps the edges of a 2d grid, the same computed by ComputeEdges, and updates them when some cells change.
+// Each row and column of the grid is recomputed independently, so that only the ones touched by the changed cells
+// need to be scanned again.
+type EdgeMap struct {
+	gridWidth, gridHeight int
+	isSolid               ComputeEdgesCallback
+	// Top and bottom segments, for each row
+	top, bottom [][]*geom.Segment
+	// Left and right segments, for each column
+	left, right [][]*geom.Segment
+}
+
+// NewEdgeMap computes the edges of the whole grid.
+// The callback has to return 'true' for each cell which is considered solid, and has to reflect the current state of
+// the grid every time the map is updated.
+func NewEdgeMap(gridWidth, gridHeight int, isSolid ComputeEdgesCallback) *EdgeMap {
+	em := &EdgeMap{
+		gridWidth:  gridWidth,
+		gridHeight: gridHeight,
+		isSolid:    isSolid,
+		top:        make([][]*geom.Segment, gridHeight),
+		bottom:     make([][]*geom.Segment, gridHeight),
+		left:       make([][]*geom.Segment, gridWidth),
+		right:      make([][]*geom.Segment, gridWidth),
+	}
+	for y := 0; y < gridHeight; y++ {
+		em.top[y], em.bottom[y] = em.computeRow(y)
+	}
+	for x := 0; x < gridWidth; x++ {
+		em.left[x], em.right[x] = em.computeColumn(x)
+	}
+	return em
+}
+
+// Segments returns all the segments of the grid, in the same order as ComputeEdges.
+// The segments not affected by an update keep their address, and can be used as keys.
+func (em *EdgeMap) Segments() []*geom.Segment {
+	type sortedSegment struct {
+		cell    geom.Point
+		side    int
+		segment *geom.Segment
+	}
+	sorted := make([]sortedSegment, 0)
+	for y := 0; y < em.gridHeight; y++ {
+		for _, segment := range em.top[y] {
+			sorted = append(sorted, sortedSegment{segment.A, 0, segment})
+		}
+		for _, segment := range em.bottom[y] {
+			sorted = append(sorted, sortedSegment{geom.Point{X: segment.B.X, Y: y}, 1, segment})
+		}
+	}
+	for x := 0; x < em.gridWidth; x++ {
+		for _, segment := range em.left[x] {
+			sorted = append(sorted, sortedSegment{segment.B, 2, segment})
+		}
+		for _, segment := range em.right[x] {
+			sorted = append(sorted, sortedSegment{geom.Point{X: x, Y: segment.A.Y}, 3, segment})
+		}
+	}
+
+	// ComputeEdges creates each segment when visiting its first cell, by rows
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.cell.Y != b.cell.Y {
+			return a.cell.Y < b.cell.Y
+		}
+		if a.cell.X != b.cell.X {
+			return a.cell.X < b.cell.X
+		}
+		return a.side < b.side
+	})
+	segments := make([]*geom.Segment, len(sorted))
+	for i := range sorted {
+		segments[i] = sorted[i].segment
+	}
+	return segments
+}
+
+// Update recomputes the edges after the cells inside the dirty rect have changed.
+// It returns the segments which have been added, and the ones which have been removed from the map.
+func (em *EdgeMap) Update(dirty geom.Rect) (added []*geom.Segment, removed []*geom.Segment) {
+	added = make([]*geom.Segment, 0)
+	removed = make([]*geom.Segment, 0)
+	dirty = dirty.Intersection(geom.Rect{W: em.gridWidth, H: em.gridHeight})
+	if dirty.Empty() {
+		return added, removed
+	}
+
+	// A cell affects the edges of its neighbours too
+	for y := dirty.Top() - 1; y <= dirty.Bottom(); y++ {
+		if y < 0 || y >= em.gridHeight {
+			continue
+		}
+		top, bottom := em.computeRow(y)
+		em.top[y] = diffSegments(em.top[y], top, &added, &removed)
+		em.bottom[y] = diffSegments(em.bottom[y], bottom, &added, &removed)
+	}
+	for x := dirty.Left() - 1; x <= dirty.Right(); x++ {
+		if x < 0 || x >= em.gridWidth {
+			continue
+		}
+		left, right := em.computeColumn(x)
+		em.left[x] = diffSegments(em.left[x], left, &added, &removed)
+		em.right[x] = diffSegments(em.right[x], right, &added, &removed)
+	}
+	return added, removed
+}
+
+// computeRow creates the merged segments at the top and at the bottom of the cells of a row
+func (em *EdgeMap) computeRow(y int) (top, bottom []*geom.Segment) {
+	top = make([]*geom.Segment, 0)
+	bottom = make([]*geom.Segment, 0)
+	var lastTop, lastBottom *geom.Segment
+	for x := 0; x < em.gridWidth; x++ {
+		if !em.isSolid(x, y) {
+			lastTop, lastBottom = nil, nil
+			continue
+		}
+		if y-1 < 0 || !em.isSolid(x, y-1) {
+			if lastTop == nil {
+				lastTop = &geom.Segment{A: geom.Point{X: x, Y: y}, B: geom.Point{X: x + 1, Y: y}}
+				top = append(top, lastTop)
+			} else {
+				lastTop.B.X++
+			}
+		} else {
+			lastTop = nil
+		}
+		if y+1 >= em.gridHeight || !em.isSolid(x, y+1) {
+			if lastBottom == nil {
+				lastBottom = &geom.Segment{A: geom.Point{X: x + 1, Y: y + 1}, B: geom.Point{X: x, Y: y + 1}}
+				bottom = append(bottom, lastBottom)
+			} else {
+				lastBottom.A.X++
+			}
+		} else {
+			lastBottom = nil
+		}
+	}
+	return top, bottom
+}
+
+// computeColumn creates the merged segments at the left and at the right of the cells of a column
+func (em *EdgeMap) computeColumn(x int) (left, right []*geom.Segment) {
+	left = make([]*geom.Segment, 0)
+	right = make([]*geom.Segment, 0)
+	var lastLeft, lastRight *geom.Segment
+	for y := 0; y < em.gridHeight; y++ {
+		if !em.isSolid(x, y) {
+			lastLeft, lastRight = nil, nil
+			continue
+		}
+		if x-1 < 0 || !em.isSolid(x-1, y) {
+			if lastLeft == nil {
+				lastLeft = &geom.Segment{A: geom.Point{X: x, Y: y + 1}, B: geom.Point{X: x, Y: y}}
+				left = append(left, lastLeft)
+			} else {
+				lastLeft.A.Y++
+			}
+		} else {
+			lastLeft = nil
+		}
+		if x+1 >= em.gridWidth || !em.isSolid(x+1, y) {
+			if lastRight == nil {
+				lastRight = &geom.Segment{A: geom.Point{X: x + 1, Y: y}, B: geom.Point{X: x + 1, Y: y + 1}}
+				right = append(right, lastRight)
+			} else {
+				lastRight.B.Y++
+			}
+		} else {
+			lastRight = nil
+		}
+	}
+	return left, right
+}
+
+// diffSegments compares the old and the new segments of a line, keeping the old ones which haven't changed.
+// It returns the updated segments of the line.
+func diffSegments(oldSegments, newSegments []*geom.Segment, added, removed *[]*geom.Segment) []*geom.Segment {
+	kept := make(map[geom.Segment]*geom.Segment, len(oldSegments))
+	for _, segment := range oldSegments {
+		kept[*segment] = segment
+	}
+	for i, segment := range newSegments {
+		if oldSegment, ok := kept[*segment]; ok {
+			newSegments[i] = oldSegment
+			delete(kept, *segment)
+		} else {
+			*added = append(*added, segment)
+		}
+	}
+	for _, segment := range oldSegments {
+		if _, ok := kept[*segment]; ok {
+			*removed = append(*removed, segment)
+		}
+	}
+	return newSegments
+}
@@ -0,0 +1,101 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/rand"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"testing"
+)
+
+func segmentValues(segments []*geom.Segment) []geom.Segment {
+	values := make([]geom.Segment, len(segments))
+	for i, segment := range segments {
+		values[i] = *segment
+	}
+	return values
+}
+
+func TestEdgeMapUpdate(t *testing.T) {
+	const gridWidth, gridHeight = 24, 16
+	rng := rand.NewHashRngWithSeed(0)
+	grid := make([]bool, gridWidth*gridHeight)
+	for i := range grid {
+		grid[i] = rng.Event(60)
+	}
+	isSolid := func(x, y int) bool { return grid[y*gridWidth+x] }
+
+	edgeMap := NewEdgeMap(gridWidth, gridHeight, isSolid)
+	testx.AssertEqual(t, "TestEdgeMapUpdate initial", segmentValues(ComputeEdges(gridWidth, gridHeight, isSolid)), segmentValues(edgeMap.Segments()))
+
+	for i := 0; i < 50; i++ {
+		// Digs, or fills, a random rect which can also be partially outside the grid
+		dirty := geom.Rect{
+			X: int(rng.NextUint32LessThan(gridWidth+2)) - 2,
+			Y: int(rng.NextUint32LessThan(gridHeight+2)) - 2,
+			W: int(rng.NextUint32InRange(1, 5)),
+			H: int(rng.NextUint32InRange(1, 5)),
+		}
+		solid := rng.Maybe()
+		for y := dirty.Top(); y < dirty.Bottom(); y++ {
+			for x := dirty.Left(); x < dirty.Right(); x++ {
+				if x >= 0 && y >= 0 && x < gridWidth && y < gridHeight {
+					grid[y*gridWidth+x] = solid
+				}
+			}
+		}
+
+		previous := make(map[*geom.Segment]bool)
+		for _, segment := range edgeMap.Segments() {
+			previous[segment] = true
+		}
+		added, removed := edgeMap.Update(dirty)
+		for _, segment := range removed {
+			delete(previous, segment)
+		}
+		for _, segment := range added {
+			previous[segment] = true
+		}
+
+		errorText := fmt.Sprintf("TestEdgeMapUpdate #%d", i)
+		segments := edgeMap.Segments()
+		testx.AssertEqual(t, errorText, segmentValues(ComputeEdges(gridWidth, gridHeight, isSolid)), segmentValues(segments))
+		// Applying the changes to the previous segments has to give the current ones
+		if len(previous) != len(segments) {
+			t.Errorf("%s failed: %d segments after applying the changes, expecting %d", errorText, len(previous), len(segments))
+		}
+		for _, segment := range segments {
+			if !previous[segment] {
+				t.Errorf("%s failed: segment %v not reported as added", errorText, *segment)
+			}
+		}
+	}
+}
+
+func TestEdgeMapUpdateSingleCell(t *testing.T) {
+	grid := [][]bool{
+		{true, true, true},
+		{true, true, true},
+	}
+	isSolid := func(x, y int) bool { return grid[y][x] }
+	edgeMap := NewEdgeMap(3, 2, isSolid)
+
+	// Digging the middle cell of the top row splits the top segment in two, and adds three new edges
+	grid[0][1] = false
+	added, removed := edgeMap.Update(geom.Rect{X: 1, Y: 0, W: 1, H: 1})
+	testx.AssertEqual(t, "TestEdgeMapUpdateSingleCell added", []geom.Segment{
+		{A: geom.Point{X: 0, Y: 0}, B: geom.Point{X: 1, Y: 0}},
+		{A: geom.Point{X: 2, Y: 0}, B: geom.Point{X: 3, Y: 0}},
+		{A: geom.Point{X: 1, Y: 1}, B: geom.Point{X: 2, Y: 1}},
+		{A: geom.Point{X: 1, Y: 0}, B: geom.Point{X: 1, Y: 1}},
+		{A: geom.Point{X: 2, Y: 1}, B: geom.Point{X: 2, Y: 0}},
+	}, segmentValues(added))
+	testx.AssertEqual(t, "TestEdgeMapUpdateSingleCell removed", []geom.Segment{
+		{A: geom.Point{X: 0, Y: 0}, B: geom.Point{X: 3, Y: 0}},
+	}, segmentValues(removed))
+
+	// Restoring the cell gives back the original edges
+	grid[0][1] = true
+	edgeMap.Update(geom.Rect{X: 1, Y: 0, W: 1, H: 1})
+	testx.AssertEqual(t, "TestEdgeMapUpdateSingleCell restored", segmentValues(ComputeEdges(3, 2, isSolid)), segmentValues(edgeMap.Segments()))
+}
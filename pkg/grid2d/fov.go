@@ -0,0 +1,131 @@
+package grid2d
+
+// Symmetric shadowcasting, as described by Albert Ford (https://www.albertford.com/shadowcasting/)
+
+import (
+	"github.com/maxfish/go-libs/pkg/imath"
+)
+
+type ComputeFOVCallback func(x int, y int) bool
+
+type FOVVisibleCallback func(x int, y int)
+
+// fovSlope is a fraction, num/den with den > 0, used to keep the computations exact
+type fovSlope struct {
+	num, den int
+}
+
+// fovRow is a row of cells at the given distance from the origin, inside a quadrant
+type fovRow struct {
+	depth      int
+	startSlope fovSlope
+	endSlope   fovSlope
+}
+
+// fovQuadrant transforms the coordinates relative to a quadrant into grid coordinates
+type fovQuadrant struct {
+	originX, originY int
+	direction        int
+}
+
+func (q fovQuadrant) transform(row, col int) (int, int) {
+	switch q.direction {
+	case 0: // North
+		return q.originX + col, q.originY - row
+	case 1: // East
+		return q.originX + row, q.originY + col
+	case 2: // South
+		return q.originX + col, q.originY + row
+	default: // West
+		return q.originX - row, q.originY + col
+	}
+}
+
+// ComputeFOV finds the cells visible from the origin, up to the given radius, using symmetric shadowcasting.
+// If a cell A can see a cell B, B can see A too. The opaque cells blocking the view are visible, but not the
+// cells behind them.
+// The isOpaque callback has to return 'true' for each cell which blocks the view.
+// The visible callback is called once for each visible cell, the origin included. Nothing is visible with a negative
+// radius.
+func ComputeFOV(gridWidth, gridHeight, originX, originY, radius int, isOpaque ComputeFOVCallback, visible FOVVisibleCallback) {
+	if radius < 0 || originX < 0 || originY < 0 || originX >= gridWidth || originY >= gridHeight {
+		return
+	}
+	visible(originX, originY)
+
+	// The cells outside the grid block the view
+	isWall := func(x, y int) bool {
+		return x < 0 || y < 0 || x >= gridWidth || y >= gridHeight || isOpaque(x, y)
+	}
+	// A radius longer than the grid sees as far as an unlimited one, and keeps radius*radius from overflowing
+	radius = imath.Min(radius, gridWidth+gridHeight)
+	// The cells on the diagonals are shared by two quadrants, they are reported only once
+	revealed := make([]bool, gridWidth*gridHeight)
+	revealed[originY*gridWidth+originX] = true
+	reveal := func(x, y int) {
+		dx, dy := x-originX, y-originY
+		if x < 0 || y < 0 || x >= gridWidth || y >= gridHeight || dx*dx+dy*dy > radius*radius {
+			return
+		}
+		index := y*gridWidth + x
+		if !revealed[index] {
+			revealed[index] = true
+			visible(x, y)
+		}
+	}
+	for direction := 0; direction < 4; direction++ {
+		quadrant := fovQuadrant{originX: originX, originY: originY, direction: direction}
+		scanFOVRow(quadrant, fovRow{depth: 1, startSlope: fovSlope{-1, 1}, endSlope: fovSlope{1, 1}}, radius, isWall, reveal)
+	}
+}
+
+func scanFOVRow(quadrant fovQuadrant, row fovRow, radius int, isWall ComputeFOVCallback, reveal FOVVisibleCallback) {
+	if row.depth > radius {
+		return
+	}
+	minCol := roundTiesUp(row.depth*row.startSlope.num, row.startSlope.den)
+	maxCol := roundTiesDown(row.depth*row.endSlope.num, row.endSlope.den)
+
+	prevIsWall, hasPrev := false, false
+	for col := minCol; col <= maxCol; col++ {
+		x, y := quadrant.transform(row.depth, col)
+		wall := isWall(x, y)
+		if wall || isSymmetric(row, col) {
+			reveal(x, y)
+		}
+		if hasPrev && prevIsWall && !wall {
+			row.startSlope = fovSlope{2*col - 1, 2 * row.depth}
+		}
+		if hasPrev && !prevIsWall && wall {
+			next := fovRow{depth: row.depth + 1, startSlope: row.startSlope, endSlope: fovSlope{2*col - 1, 2 * row.depth}}
+			scanFOVRow(quadrant, next, radius, isWall, reveal)
+		}
+		prevIsWall, hasPrev = wall, true
+	}
+	if hasPrev && !prevIsWall {
+		scanFOVRow(quadrant, fovRow{depth: row.depth + 1, startSlope: row.startSlope, endSlope: row.endSlope}, radius, isWall, reveal)
+	}
+}
+
+// isSymmetric checks if the center of the cell is inside the sector of the row
+func isSymmetric(row fovRow, col int) bool {
+	return col*row.startSlope.den >= row.depth*row.startSlope.num && col*row.endSlope.den <= row.depth*row.endSlope.num
+}
+
+// roundTiesUp rounds num/den to the nearest integer, rounding up the halves
+func roundTiesUp(num, den int) int {
+	return floorDiv(2*num+den, 2*den)
+}
+
+// roundTiesDown rounds num/den to the nearest integer, rounding down the halves
+func roundTiesDown(num, den int) int {
+	return -floorDiv(-2*num+den, 2*den)
+}
+
+// floorDiv divides rounding towards negative infinity, den has to be positive
+func floorDiv(num, den int) int {
+	if num < 0 {
+		return -((-num + den - 1) / den)
+	}
+	return num / den
+}
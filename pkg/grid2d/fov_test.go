@@ -0,0 +1,115 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/rand"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"strings"
+	"testing"
+)
+
+// fovToStrings marks the visible cells of the grid with 'o' (or 'O' for the solid ones), the others with '.'
+func fovToStrings(rows []string, originX, originY, radius int) []string {
+	w, h, isSolid := gridFromStrings(rows)
+	visible := make([][]byte, h)
+	for y := range visible {
+		visible[y] = []byte(strings.Repeat(".", w))
+	}
+	ComputeFOV(w, h, originX, originY, radius, ComputeFOVCallback(isSolid), func(x, y int) {
+		if visible[y][x] != '.' {
+			panic(fmt.Sprintf("cell %d,%d reported twice", x, y))
+		}
+		if isSolid(x, y) {
+			visible[y][x] = 'O'
+		} else {
+			visible[y][x] = 'o'
+		}
+	})
+	result := make([]string, h)
+	for y := range visible {
+		result[y] = string(visible[y])
+	}
+	return result
+}
+
+func TestComputeFOV(t *testing.T) {
+	var tests = []struct {
+		grid             []string
+		originX, originY int
+		radius           int
+		visible          []string
+	}{
+		// The radius limits the view to a circle
+		{[]string{".......", ".......", ".......", ".......", ".......", ".......", "......."}, 3, 3, 2, []string{
+			".......",
+			"...o...",
+			"..ooo..",
+			".ooooo.",
+			"..ooo..",
+			"...o...",
+			".......",
+		}},
+		// A pillar casts a shadow, and the walls hide the cells behind them
+		{[]string{"#######", "#.....#", "#.....#", "#..#..#", "#.....#", "#.....#", "#######"}, 3, 5, 10, []string{
+			"OOO.OOO",
+			"Ooo.ooO",
+			"Ooo.ooO",
+			"OooOooO",
+			"OoooooO",
+			"OoooooO",
+			"OOOOOOO",
+		}},
+		// Corridor
+		{[]string{"#####", "#...#", "#####"}, 1, 1, 10, []string{
+			"OOOOO",
+			"OoooO",
+			"OOOOO",
+		}},
+		// With a radius of 0 only the origin is visible, with a negative one nothing is
+		{[]string{"...", "...", "..."}, 1, 1, 0, []string{"...", ".o.", "..."}},
+		{[]string{"...", "...", "..."}, 1, 1, -1, []string{"...", "...", "..."}},
+		// A huge radius means unlimited
+		{[]string{"#####", "#...#", "#####"}, 1, 1, 1 << 40, []string{
+			"OOOOO",
+			"OoooO",
+			"OOOOO",
+		}},
+	}
+
+	for i, test := range tests {
+		visible := fovToStrings(test.grid, test.originX, test.originY, test.radius)
+		testx.AssertEqual(t, fmt.Sprintf("TestComputeFOV #%d", i), test.visible, visible)
+	}
+}
+
+func TestComputeFOVSymmetry(t *testing.T) {
+	const gridWidth, gridHeight, radius = 20, 20, 8
+	rng := rand.NewHashRngWithSeed(0)
+	opaque := make([]bool, gridWidth*gridHeight)
+	for i := range opaque {
+		opaque[i] = rng.Event(25)
+	}
+	isOpaque := func(x, y int) bool { return opaque[y*gridWidth+x] }
+
+	visibility := make([][]bool, gridWidth*gridHeight)
+	for origin := range visibility {
+		visibility[origin] = make([]bool, gridWidth*gridHeight)
+		if opaque[origin] {
+			continue
+		}
+		ComputeFOV(gridWidth, gridHeight, origin%gridWidth, origin/gridWidth, radius, isOpaque, func(x, y int) {
+			visibility[origin][y*gridWidth+x] = true
+		})
+	}
+	for a := range visibility {
+		for b := range visibility {
+			if opaque[a] || opaque[b] {
+				continue
+			}
+			if visibility[a][b] != visibility[b][a] {
+				t.Fatalf("TestComputeFOVSymmetry failed: %d,%d sees %d,%d: %v, the opposite: %v",
+					a%gridWidth, a/gridWidth, b%gridWidth, b/gridWidth, visibility[a][b], visibility[b][a])
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package grid2d
+
+import (
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+)
+
+// Grid is a 2d grid of values, stored by rows in a single slice
+type Grid[T any] struct {
+	width, height int
+	cells         []T
+}
+
+// NewGrid creates a grid with all the cells set to the zero value. Negative sizes are treated as 0.
+func NewGrid[T any](width, height int) *Grid[T] {
+	width, height = imath.Max(width, 0), imath.Max(height, 0)
+	return &Grid[T]{
+		width:  width,
+		height: height,
+		cells:  make([]T, width*height),
+	}
+}
+
+func (g *Grid[T]) Width() int  { return g.width }
+func (g *Grid[T]) Height() int { return g.height }
+
+// Cells returns the values of the cells, stored by rows. Changing them changes the grid.
+func (g *Grid[T]) Cells() []T { return g.cells }
+
+// Contains checks if the cell is inside the grid
+func (g *Grid[T]) Contains(x, y int) bool {
+	return x >= 0 && y >= 0 && x < g.width && y < g.height
+}
+
+// Get returns the value of the cell. It returns false if the cell is outside the grid.
+func (g *Grid[T]) Get(x, y int) (T, bool) {
+	if !g.Contains(x, y) {
+		var zero T
+		return zero, false
+	}
+	return g.cells[y*g.width+x], true
+}
+
+// Set changes the value of the cell. It returns false if the cell is outside the grid.
+func (g *Grid[T]) Set(x, y int, value T) bool {
+	if !g.Contains(x, y) {
+		return false
+	}
+	g.cells[y*g.width+x] = value
+	return true
+}
+
+// Fill sets all the cells to the value
+func (g *Grid[T]) Fill(value T) {
+	for i := range g.cells {
+		g.cells[i] = value
+	}
+}
+
+// Neighbours calls the callback for each neighbour of the cell inside the grid, horizontal and vertical ones first.
+// When diagonals is true the 8 surrounding cells are visited, otherwise only 4.
+func (g *Grid[T]) Neighbours(x, y int, diagonals bool, callback func(neighbourX, neighbourY int, value T)) {
+	numDirections := 4
+	if diagonals {
+		numDirections = 8
+	}
+	for i := 0; i < numDirections; i++ {
+		neighbourX, neighbourY := x+pathDirections[i].X, y+pathDirections[i].Y
+		if g.Contains(neighbourX, neighbourY) {
+			callback(neighbourX, neighbourY, g.cells[neighbourY*g.width+neighbourX])
+		}
+	}
+}
+
+// FloodFill sets to the value all the cells which match the predicate, and are horizontally or vertically connected
+// to the starting one through other matching cells. It returns the number of cells changed.
+func (g *Grid[T]) FloodFill(x, y int, matches func(value T) bool, value T) int {
+	return ScanlineFloodFill(g.width, g.height, x, y, false, g.Callback(matches), func(cellX, cellY int) {
+		g.cells[cellY*g.width+cellX] = value
+	})
+}
+
+// SubGrid returns a copy of the cells inside the rect. The rect is clipped to the grid.
+func (g *Grid[T]) SubGrid(rect geom.Rect) *Grid[T] {
+	rect = rect.Intersection(geom.Rect{W: g.width, H: g.height})
+	subGrid := NewGrid[T](rect.W, rect.H)
+	subGrid.CopyFrom(g, rect, 0, 0)
+	return subGrid
+}
+
+// CopyFrom copies the cells inside the rect of the source grid, placing its top-left corner at x,y.
+// The cells falling outside either grid are skipped.
+func (g *Grid[T]) CopyFrom(source *Grid[T], sourceRect geom.Rect, x, y int) {
+	clipped := sourceRect.Intersection(geom.Rect{W: source.width, H: source.height})
+	if clipped.Empty() {
+		return
+	}
+	// The cells clipped at the top-left of the rect shift the destination too
+	x += clipped.X - sourceRect.X
+	y += clipped.Y - sourceRect.Y
+	sourceRect = clipped
+	destinationRect := geom.Rect{X: x, Y: y, W: sourceRect.W, H: sourceRect.H}.Intersection(geom.Rect{W: g.width, H: g.height})
+	if destinationRect.Empty() {
+		return
+	}
+	offsetX := sourceRect.X - x
+	offsetY := sourceRect.Y - y
+	for row := destinationRect.Top(); row < destinationRect.Bottom(); row++ {
+		sourceStart := (row+offsetY)*source.width + destinationRect.Left() + offsetX
+		destinationStart := row*g.width + destinationRect.Left()
+		copy(g.cells[destinationStart:destinationStart+destinationRect.W], source.cells[sourceStart:sourceStart+destinationRect.W])
+	}
+}
+
+// Resize changes the size of the grid, keeping the cells at the top-left. The new cells are set to the fill value.
+// Negative sizes are treated as 0.
+func (g *Grid[T]) Resize(width, height int, fill T) {
+	width, height = imath.Max(width, 0), imath.Max(height, 0)
+	cells := make([]T, width*height)
+	for i := range cells {
+		cells[i] = fill
+	}
+	minWidth := imath.Min(width, g.width)
+	for y := 0; y < imath.Min(height, g.height); y++ {
+		copy(cells[y*width:y*width+minWidth], g.cells[y*g.width:y*g.width+minWidth])
+	}
+	g.width, g.height, g.cells = width, height, cells
+}
+
+// Callback adapts the grid to the functions taking a callback, returning 'true' for the cells matching the predicate
+func (g *Grid[T]) Callback(predicate func(value T) bool) func(x, y int) bool {
+	return func(x, y int) bool {
+		return predicate(g.cells[y*g.width+x])
+	}
+}
+
+// ComputeEdges creates a list of segments covering all edges of the grid, see ComputeEdges
+func (g *Grid[T]) ComputeEdges(isSolid func(value T) bool) []*geom.Segment {
+	return ComputeEdges(g.width, g.height, g.Callback(isSolid))
+}
+
+// IterateRay walks the cells of the grid crossed by the ray, see IterateRay
+func (g *Grid[T]) IterateRay(cellSize int, rayX, rayY, rayDirX, rayDirY float32, canContinue func(tileX, tileY int, x, y float32, value T) bool) {
+	IterateRay(cellSize, g.width, g.height, rayX, rayY, rayDirX, rayDirY, func(tileX, tileY int, x, y float32) bool {
+		return canContinue(tileX, tileY, x, y, g.cells[tileY*g.width+tileX])
+	})
+}
@@ -0,0 +1,122 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"strings"
+	"testing"
+)
+
+// gridOfRunes creates a grid with a list of rows, one rune per cell
+func gridOfRunes(rows []string) *Grid[rune] {
+	grid := NewGrid[rune](len(rows[0]), len(rows))
+	for y, row := range rows {
+		for x, value := range row {
+			grid.Set(x, y, value)
+		}
+	}
+	return grid
+}
+
+func runeGridToString(grid *Grid[rune]) string {
+	var builder strings.Builder
+	for y := 0; y < grid.Height(); y++ {
+		for x := 0; x < grid.Width(); x++ {
+			value, _ := grid.Get(x, y)
+			builder.WriteRune(value)
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func TestGridGetSet(t *testing.T) {
+	grid := NewGrid[int](3, 2)
+	testx.AssertEqual(t, "TestGridGetSet set", true, grid.Set(2, 1, 5))
+	testx.AssertEqual(t, "TestGridGetSet set outside", false, grid.Set(3, 1, 5))
+	value, ok := grid.Get(2, 1)
+	testx.AssertEqual(t, "TestGridGetSet get", []interface{}{5, true}, []interface{}{value, ok})
+	value, ok = grid.Get(-1, 0)
+	testx.AssertEqual(t, "TestGridGetSet get outside", []interface{}{0, false}, []interface{}{value, ok})
+	testx.AssertEqual(t, "TestGridGetSet cells", []int{0, 0, 0, 0, 0, 5}, grid.Cells())
+}
+
+func TestGridNeighbours(t *testing.T) {
+	grid := gridOfRunes([]string{"abc", "def", "ghi"})
+	var tests = []struct {
+		x, y       int
+		diagonals  bool
+		neighbours string
+	}{
+		{1, 1, false, "fhdb"},
+		{1, 1, true, "fhdbigac"},
+		{0, 0, true, "bde"},
+		{2, 2, false, "hf"},
+	}
+	for i, test := range tests {
+		neighbours := ""
+		grid.Neighbours(test.x, test.y, test.diagonals, func(neighbourX, neighbourY int, value rune) {
+			neighbours += string(value)
+		})
+		testx.AssertEqual(t, fmt.Sprintf("TestGridNeighbours #%d", i), test.neighbours, neighbours)
+	}
+}
+
+func TestGridFloodFill(t *testing.T) {
+	grid := gridOfRunes([]string{
+		"..#..",
+		"..#..",
+		"###..",
+		"....#",
+	})
+	isEmpty := func(value rune) bool { return value == '.' }
+	testx.AssertEqual(t, "TestGridFloodFill count", 4, grid.FloodFill(1, 1, isEmpty, 'o'))
+	testx.AssertEqual(t, "TestGridFloodFill", "oo#..\noo#..\n###..\n....#\n", runeGridToString(grid))
+
+	// The new value matches the predicate
+	testx.AssertEqual(t, "TestGridFloodFill same value", 10, grid.FloodFill(4, 0, isEmpty, '.'))
+	testx.AssertEqual(t, "TestGridFloodFill solid", 0, grid.FloodFill(2, 0, isEmpty, 'o'))
+}
+
+func TestGridSubGridAndResize(t *testing.T) {
+	grid := gridOfRunes([]string{"abcd", "efgh", "ijkl"})
+	testx.AssertEqual(t, "TestGridSubGridAndResize sub-grid", "fg\njk\n", runeGridToString(grid.SubGrid(geom.Rect{X: 1, Y: 1, W: 2, H: 5})))
+
+	destination := gridOfRunes([]string{"....", "...."})
+	destination.CopyFrom(grid, geom.Rect{X: 0, Y: 0, W: 3, H: 3}, 2, -1)
+	testx.AssertEqual(t, "TestGridSubGridAndResize copy", "..ef\n..ij\n", runeGridToString(destination))
+
+	// The parts of the rects outside the source grid are skipped, without moving the rest
+	destination = gridOfRunes([]string{"....", "...."})
+	destination.CopyFrom(grid, geom.Rect{X: -2, Y: -1, W: 4, H: 2}, 0, 0)
+	testx.AssertEqual(t, "TestGridSubGridAndResize copy negative", "....\n..ab\n", runeGridToString(destination))
+	testx.AssertEqual(t, "TestGridSubGridAndResize sub-grid negative", "ab\nef\n", runeGridToString(grid.SubGrid(geom.Rect{X: -2, Y: -1, W: 4, H: 3})))
+	testx.AssertEqual(t, "TestGridSubGridAndResize sub-grid outside", "", runeGridToString(grid.SubGrid(geom.Rect{X: -5, Y: 0, W: 2, H: 2})))
+	testx.AssertEqual(t, "TestGridSubGridAndResize negative size", [2]int{0, 0}, [2]int{NewGrid[rune](-1, 3).Width(), NewGrid[rune](3, -1).Height()})
+
+	grid.Resize(5, 2, '.')
+	testx.AssertEqual(t, "TestGridSubGridAndResize grow", "abcd.\nefgh.\n", runeGridToString(grid))
+	grid.Resize(2, 3, '.')
+	testx.AssertEqual(t, "TestGridSubGridAndResize shrink", "ab\nef\n..\n", runeGridToString(grid))
+	grid.Resize(-1, 2, '.')
+	testx.AssertEqual(t, "TestGridSubGridAndResize negative resize", [2]int{0, 2}, [2]int{grid.Width(), grid.Height()})
+}
+
+func TestGridComputeEdges(t *testing.T) {
+	rows := []string{"##.", "#..", "###"}
+	grid := gridOfRunes(rows)
+	w, h, isSolid := gridFromStrings(rows)
+	segments := grid.ComputeEdges(func(value rune) bool { return value == '#' })
+	testx.AssertEqual(t, "TestGridComputeEdges", segmentValues(ComputeEdges(w, h, isSolid)), segmentValues(segments))
+}
+
+func TestGridIterateRay(t *testing.T) {
+	grid := gridOfRunes([]string{"abc", "def", "ghi"})
+	values := ""
+	grid.IterateRay(32, 16, 16, 1, 1, func(tileX, tileY int, x, y float32, value rune) bool {
+		values += string(value)
+		return value != 'e'
+	})
+	testx.AssertEqual(t, "TestGridIterateRay", "ade", values)
+}
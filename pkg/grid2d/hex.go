@@ -0,0 +1,229 @@
+package grid2d
+
+// Hexagonal grids, as described by Amit Patel (https://www.redblobgames.com/grids/hexagons/)
+
+import (
+	"github.com/maxfish/go-libs/pkg/fgeom"
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"github.com/maxfish/go-libs/pkg/imath"
+	"math"
+)
+
+// Hex identifies a cell of a hexagonal grid with axial coordinates
+type Hex struct {
+	Q, R int
+}
+
+// HexCube identifies a cell of a hexagonal grid with cube coordinates, where Q+R+S is always 0
+type HexCube struct {
+	Q, R, S int
+}
+
+// HexDirections lists the offsets of the 6 neighbours of a hex
+var HexDirections = [6]Hex{{Q: 1, R: 0}, {Q: 1, R: -1}, {Q: 0, R: -1}, {Q: -1, R: 0}, {Q: -1, R: 1}, {Q: 0, R: 1}}
+
+func (h Hex) S() int { return -h.Q - h.R }
+
+func (h Hex) ToCube() HexCube { return HexCube{Q: h.Q, R: h.R, S: h.S()} }
+
+func (c HexCube) ToAxial() Hex { return Hex{Q: c.Q, R: c.R} }
+
+func (h Hex) Add(other Hex) Hex {
+	return Hex{Q: h.Q + other.Q, R: h.R + other.R}
+}
+
+func (h Hex) Sub(other Hex) Hex {
+	return Hex{Q: h.Q - other.Q, R: h.R - other.R}
+}
+
+func (h Hex) Scale(factor int) Hex {
+	return Hex{Q: h.Q * factor, R: h.R * factor}
+}
+
+// Neighbour returns the adjacent hex in one of the HexDirections
+func (h Hex) Neighbour(direction int) Hex {
+	return h.Add(HexDirections[direction])
+}
+
+func (h Hex) Neighbours() [6]Hex {
+	var neighbours [6]Hex
+	for i := range HexDirections {
+		neighbours[i] = h.Neighbour(i)
+	}
+	return neighbours
+}
+
+// Distance returns the number of steps needed to go from a hex to the other
+func (h Hex) Distance(other Hex) int {
+	delta := h.Sub(other)
+	return (imath.Abs(delta.Q) + imath.Abs(delta.R) + imath.Abs(delta.S())) / 2
+}
+
+// RoundHex returns the hex containing the point with fractional axial coordinates
+func RoundHex(q, r float32) Hex {
+	s := -q - r
+	roundedQ, roundedR, roundedS := fmath.Round(q), fmath.Round(r), fmath.Round(s)
+	deltaQ, deltaR, deltaS := fmath.Abs(roundedQ-q), fmath.Abs(roundedR-r), fmath.Abs(roundedS-s)
+	// The coordinate with the biggest rounding error is recomputed from the other two
+	if deltaQ > deltaR && deltaQ > deltaS {
+		roundedQ = -roundedR - roundedS
+	} else if deltaR > deltaS {
+		roundedR = -roundedQ - roundedS
+	}
+	return Hex{Q: int(roundedQ), R: int(roundedR)}
+}
+
+// HexLine returns the hexes crossed by the straight line between two hexes, both included
+func HexLine(a, b Hex) []Hex {
+	n := a.Distance(b)
+	line := make([]Hex, 0, n+1)
+	// The start is nudged, so that the points on the sides between two hexes are always rounded the same way
+	startQ, startR := float32(a.Q)+1e-6, float32(a.R)+2e-6
+	endQ, endR := float32(b.Q)+1e-6, float32(b.R)+2e-6
+	for i := 0; i <= n; i++ {
+		t := float32(0)
+		if n > 0 {
+			t = float32(i) / float32(n)
+		}
+		line = append(line, RoundHex(fmath.Lerp(startQ, endQ, t), fmath.Lerp(startR, endR, t)))
+	}
+	return line
+}
+
+// HexRing returns the hexes at the given distance from the center, going around it
+func HexRing(center Hex, radius int) []Hex {
+	if radius <= 0 {
+		return []Hex{center}
+	}
+	ring := make([]Hex, 0, 6*radius)
+	hex := center.Add(HexDirections[4].Scale(radius))
+	for direction := 0; direction < 6; direction++ {
+		for i := 0; i < radius; i++ {
+			ring = append(ring, hex)
+			hex = hex.Neighbour(direction)
+		}
+	}
+	return ring
+}
+
+// HexSpiral returns the hexes within the given distance from the center, one ring after the other starting from the
+// center
+func HexSpiral(center Hex, radius int) []Hex {
+	spiral := make([]Hex, 0, 1+3*radius*(radius+1))
+	spiral = append(spiral, center)
+	for ring := 1; ring <= radius; ring++ {
+		spiral = append(spiral, HexRing(center, ring)...)
+	}
+	return spiral
+}
+
+type HexOrientation int
+
+const (
+	// The hexes have a vertex at the top, and are aligned in rows
+	HexPointyTop HexOrientation = iota
+	// The hexes have a side at the top, and are aligned in columns
+	HexFlatTop
+)
+
+// hexMatrix converts between axial coordinates and pixels
+type hexMatrix struct {
+	f0, f1, f2, f3 float32
+	b0, b1, b2, b3 float32
+	startAngle     float32
+}
+
+var sqrt3 = fmath.Sqrt(3)
+
+var hexMatrices = [2]hexMatrix{
+	HexPointyTop: {f0: sqrt3, f1: sqrt3 / 2, f2: 0, f3: 3.0 / 2, b0: sqrt3 / 3, b1: -1.0 / 3, b2: 0, b3: 2.0 / 3, startAngle: 0.5},
+	HexFlatTop:   {f0: 3.0 / 2, f1: 0, f2: sqrt3 / 2, f3: sqrt3, b0: 2.0 / 3, b1: 0, b2: -1.0 / 3, b3: sqrt3 / 3, startAngle: 0},
+}
+
+// HexLayout places a hexagonal grid in pixel space
+type HexLayout struct {
+	Orientation HexOrientation
+	// The distance between the center and the vertices of a hex, horizontally and vertically
+	Size fgeom.Size
+	// The center of the hex 0,0
+	Origin fgeom.Point
+}
+
+// HexToPixel returns the center of the hex
+func (l HexLayout) HexToPixel(hex Hex) fgeom.Point {
+	m := hexMatrices[l.Orientation]
+	return fgeom.Point{
+		X: (m.f0*float32(hex.Q)+m.f1*float32(hex.R))*l.Size.W + l.Origin.X,
+		Y: (m.f2*float32(hex.Q)+m.f3*float32(hex.R))*l.Size.H + l.Origin.Y,
+	}
+}
+
+// PixelToHex returns the hex containing the point
+func (l HexLayout) PixelToHex(x, y float32) Hex {
+	m := hexMatrices[l.Orientation]
+	pointX := (x - l.Origin.X) / l.Size.W
+	pointY := (y - l.Origin.Y) / l.Size.H
+	return RoundHex(m.b0*pointX+m.b1*pointY, m.b2*pointX+m.b3*pointY)
+}
+
+// HexCorners returns the vertices of the hex, clockwise with the Y axis pointing down
+func (l HexLayout) HexCorners(hex Hex) [6]fgeom.Point {
+	m := hexMatrices[l.Orientation]
+	center := l.HexToPixel(hex)
+	var corners [6]fgeom.Point
+	for i := range corners {
+		angle := 2 * math.Pi * float64(m.startAngle+float32(i)) / 6
+		corners[i] = fgeom.Point{
+			X: center.X + l.Size.W*float32(math.Cos(angle)),
+			Y: center.Y + l.Size.H*float32(math.Sin(angle)),
+		}
+	}
+	return corners
+}
+
+// HexRayCallback is called for each hex crossed by the ray, with the point where the ray enters it.
+// It has to return false to stop the ray.
+type HexRayCallback func(hex Hex, x, y float32) bool
+
+// IterateHexRay walks the hexes crossed by the ray, up to maxDistance pixels from its origin
+func IterateHexRay(layout HexLayout, rayX, rayY, rayDirX, rayDirY, maxDistance float32, canContinue HexRayCallback) {
+	length := fmath.Sqrt(rayDirX*rayDirX + rayDirY*rayDirY)
+	if length == 0 {
+		return
+	}
+	rayDirX /= length
+	rayDirY /= length
+
+	hex := layout.PixelToHex(rayX, rayY)
+	distance := float32(0)
+	for distance <= maxDistance {
+		if !canContinue(hex, rayX+rayDirX*distance, rayY+rayDirY*distance) {
+			return
+		}
+
+		// The ray leaves the hex through the first side it crosses, among the ones it's moving towards
+		corners := layout.HexCorners(hex)
+		exit := float32(math.MaxFloat32)
+		for i := range corners {
+			a, b := corners[i], corners[(i+1)%6]
+			// The corners are clockwise, so the outward normal of the side points to its left
+			normalX, normalY := b.Y-a.Y, a.X-b.X
+			speed := rayDirX*normalX + rayDirY*normalY
+			if speed <= 0 {
+				continue
+			}
+			exit = fmath.Min(exit, ((a.X-rayX)*normalX+(a.Y-rayY)*normalY)/speed)
+		}
+		if exit < distance {
+			exit = distance
+		}
+
+		// Steps just past the side, to find the next hex
+		next := hex
+		for step := float32(1e-3); next == hex; step *= 2 {
+			next = layout.PixelToHex(rayX+rayDirX*(exit+step), rayY+rayDirY*(exit+step))
+		}
+		hex = next
+		distance = exit
+	}
+}
@@ -0,0 +1,109 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/fgeom"
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"testing"
+)
+
+func TestHexDistanceAndNeighbours(t *testing.T) {
+	center := Hex{Q: 1, R: -2}
+	for i, neighbour := range center.Neighbours() {
+		testx.AssertEqual(t, fmt.Sprintf("TestHexDistanceAndNeighbours #%d", i), 1, center.Distance(neighbour))
+	}
+	testx.AssertEqual(t, "TestHexDistanceAndNeighbours cube", HexCube{Q: 1, R: -2, S: 1}, center.ToCube())
+	testx.AssertEqual(t, "TestHexDistanceAndNeighbours axial", center, center.ToCube().ToAxial())
+	testx.AssertEqual(t, "TestHexDistanceAndNeighbours distance", 7, Hex{Q: 3, R: -7}.Distance(Hex{Q: 0, R: 0}))
+	testx.AssertEqual(t, "TestHexDistanceAndNeighbours distance", 4, Hex{Q: -2, R: 4}.Distance(Hex{Q: 1, R: 0}))
+}
+
+func TestHexLine(t *testing.T) {
+	testx.AssertEqual(t, "TestHexLine", []Hex{{Q: 0, R: 0}, {Q: 0, R: -1}, {Q: 0, R: -2}, {Q: 0, R: -3}, {Q: 1, R: -4}, {Q: 1, R: -5}, {Q: 1, R: -6}, {Q: 1, R: -7}},
+		HexLine(Hex{Q: 0, R: 0}, Hex{Q: 1, R: -7}))
+	testx.AssertEqual(t, "TestHexLine single", []Hex{{Q: 2, R: 2}}, HexLine(Hex{Q: 2, R: 2}, Hex{Q: 2, R: 2}))
+
+	// Each hex of the line is next to the previous one
+	line := HexLine(Hex{Q: -3, R: 5}, Hex{Q: 4, R: -1})
+	for i := 1; i < len(line); i++ {
+		testx.AssertEqual(t, fmt.Sprintf("TestHexLine #%d", i), 1, line[i].Distance(line[i-1]))
+	}
+}
+
+func TestHexRingAndSpiral(t *testing.T) {
+	center := Hex{Q: 2, R: -1}
+	testx.AssertEqual(t, "TestHexRingAndSpiral ring 0", []Hex{center}, HexRing(center, 0))
+	for radius := 1; radius < 5; radius++ {
+		ring := HexRing(center, radius)
+		testx.AssertEqual(t, fmt.Sprintf("TestHexRingAndSpiral ring %d", radius), 6*radius, len(ring))
+		for i, hex := range ring {
+			testx.AssertEqual(t, fmt.Sprintf("TestHexRingAndSpiral ring %d #%d", radius, i), radius, hex.Distance(center))
+			testx.AssertEqual(t, fmt.Sprintf("TestHexRingAndSpiral ring %d step #%d", radius, i), 1, hex.Distance(ring[(i+1)%len(ring)]))
+		}
+	}
+
+	spiral := HexSpiral(center, 3)
+	unique := make(map[Hex]bool)
+	for _, hex := range spiral {
+		unique[hex] = true
+	}
+	testx.AssertEqual(t, "TestHexRingAndSpiral spiral", 37, len(unique))
+	testx.AssertEqual(t, "TestHexRingAndSpiral spiral", 37, len(spiral))
+}
+
+func TestHexLayout(t *testing.T) {
+	for _, orientation := range []HexOrientation{HexPointyTop, HexFlatTop} {
+		layout := HexLayout{Orientation: orientation, Size: fgeom.Size{W: 10, H: 12}, Origin: fgeom.Point{X: 35, Y: -20}}
+		for i, hex := range HexSpiral(Hex{}, 4) {
+			errorText := fmt.Sprintf("TestHexLayout orientation:%d #%d", orientation, i)
+			center := layout.HexToPixel(hex)
+			testx.AssertEqual(t, errorText, hex, layout.PixelToHex(center.X, center.Y))
+			// Just inside each corner
+			for _, corner := range layout.HexCorners(hex) {
+				testx.AssertEqual(t, errorText, hex, layout.PixelToHex(fmath.Lerp(corner.X, center.X, 0.01), fmath.Lerp(corner.Y, center.Y, 0.01)))
+			}
+		}
+	}
+
+	layout := HexLayout{Orientation: HexPointyTop, Size: fgeom.Size{W: 10, H: 10}}
+	testx.AssertEqual(t, "TestHexLayout pointy", fgeom.Point{X: 10 * sqrt3, Y: 0}, layout.HexToPixel(Hex{Q: 1, R: 0}))
+	testx.AssertEqual(t, "TestHexLayout pointy corner", fgeom.Point{X: 0, Y: 10}, roundPoint(layout.HexCorners(Hex{})[1]))
+	layout.Orientation = HexFlatTop
+	testx.AssertEqual(t, "TestHexLayout flat", fgeom.Point{X: 15, Y: 5 * sqrt3}, layout.HexToPixel(Hex{Q: 1, R: 0}))
+	testx.AssertEqual(t, "TestHexLayout flat corner", fgeom.Point{X: 10, Y: 0}, roundPoint(layout.HexCorners(Hex{})[0]))
+}
+
+func roundPoint(point fgeom.Point) fgeom.Point {
+	return fgeom.Point{X: fmath.Round(point.X*1000) / 1000, Y: fmath.Round(point.Y*1000) / 1000}
+}
+
+func TestIterateHexRay(t *testing.T) {
+	layout := HexLayout{Orientation: HexPointyTop, Size: fgeom.Size{W: 10, H: 10}}
+	width := 10 * sqrt3
+
+	// Along a row, entering each hex in the middle of its side
+	hexes := make([]Hex, 0)
+	entries := make([]float32, 0)
+	IterateHexRay(layout, 0, 0, 1, 0, 4*width, func(hex Hex, x, y float32) bool {
+		hexes = append(hexes, hex)
+		entries = append(entries, fmath.Round(x*100)/100)
+		return true
+	})
+	testx.AssertEqual(t, "TestIterateHexRay hexes", []Hex{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}}, hexes)
+	testx.AssertEqual(t, "TestIterateHexRay entries", []float32{0, fmath.Round(width*50) / 100, fmath.Round(width*150) / 100,
+		fmath.Round(width*250) / 100, fmath.Round(width*350) / 100}, entries)
+
+	// Any ray walks through adjacent hexes, and it can be stopped
+	for i, direction := range [][2]float32{{1, 1}, {-3, 1}, {0, -1}, {0.2, 5}, {-1, -1.7}} {
+		hexes = hexes[:0]
+		IterateHexRay(layout, 3, 4, direction[0], direction[1], 200, func(hex Hex, x, y float32) bool {
+			hexes = append(hexes, hex)
+			return len(hexes) < 8
+		})
+		testx.AssertEqual(t, fmt.Sprintf("TestIterateHexRay stop #%d", i), 8, len(hexes))
+		for j := 1; j < len(hexes); j++ {
+			testx.AssertEqual(t, fmt.Sprintf("TestIterateHexRay adjacent #%d %d", i, j), 1, hexes[j].Distance(hexes[j-1]))
+		}
+	}
+}
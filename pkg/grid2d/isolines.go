@@ -0,0 +1,138 @@
+package grid2d
+
+import (
+	"github.com/maxfish/go-libs/pkg/fgeom"
+	"github.com/maxfish/go-libs/pkg/fmath"
+)
+
+// ScalarFieldCallback returns the value of the field at a point of the grid
+type ScalarFieldCallback func(x int, y int) float32
+
+// Isoline is a polyline following the points where the field has the threshold value
+type Isoline struct {
+	Points []fgeom.Point
+	// The last point is connected to the first one. The isolines reaching the borders of the grid are open.
+	Closed bool
+}
+
+const isolineMinT = 1e-4
+
+// isolineCorners lists the corners of a square, clockwise with the Y axis pointing down
+var isolineCorners = [4][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+
+// ComputeIsolineSegments creates the segments separating the values of the field above the threshold from the others,
+// using marching squares. The field is sampled at each point of the grid, and the coordinates of the segments are
+// interpolated linearly between them. Walking along the segments, the values above the threshold are on the right
+// side (with the Y axis pointing down), like the solid cells with ComputeEdges.
+func ComputeIsolineSegments(gridWidth, gridHeight int, value ScalarFieldCallback, threshold float32) []fgeom.Segment {
+	segments := make([]fgeom.Segment, 0)
+	var values [4]float32
+	var crossings [4]fgeom.Point
+	var exits [4]bool
+	for y := 0; y < gridHeight-1; y++ {
+		for x := 0; x < gridWidth-1; x++ {
+			numInside := 0
+			for i, corner := range isolineCorners {
+				values[i] = value(x+corner[0], y+corner[1])
+				if values[i] > threshold {
+					numInside++
+				}
+			}
+			if numInside == 0 || numInside == 4 {
+				continue
+			}
+
+			// Finds where the sides of the square are crossed, going clockwise
+			for i := range isolineCorners {
+				next := (i + 1) % 4
+				inside, nextInside := values[i] > threshold, values[next] > threshold
+				if inside == nextInside {
+					continue
+				}
+				exits[i] = inside
+				crossings[i] = interpolateIsolinePoint(x, y, i, next, values[i], values[next], threshold)
+			}
+
+			// Each exit is joined to an entry. In the saddle cases, when the center of the square is above the
+			// threshold the two inside corners are connected, otherwise they are separated.
+			centerInside := (values[0]+values[1]+values[2]+values[3])/4 > threshold
+			for i := range isolineCorners {
+				next := (i + 1) % 4
+				if (values[i] > threshold) == (values[next] > threshold) || !exits[i] {
+					continue
+				}
+				for step := 1; step < 4; step++ {
+					j := (i + step) % 4
+					if !centerInside {
+						j = (i + 4 - step) % 4
+					}
+					if (values[j] > threshold) != (values[(j+1)%4] > threshold) && !exits[j] {
+						segments = append(segments, fgeom.Segment{A: crossings[i], B: crossings[j]})
+						break
+					}
+				}
+			}
+		}
+	}
+	return segments
+}
+
+// interpolateIsolinePoint finds where the field has the threshold value, on the side of the square between two corners.
+// The point is always computed in the same direction, so that the squares sharing the side find the same point.
+func interpolateIsolinePoint(x, y, a, b int, valueA, valueB, threshold float32) fgeom.Point {
+	cornerA, cornerB := isolineCorners[a], isolineCorners[b]
+	if cornerA[0] > cornerB[0] || cornerA[1] > cornerB[1] {
+		cornerA, cornerB = cornerB, cornerA
+		valueA, valueB = valueB, valueA
+	}
+	// The points are kept strictly inside the side, even when a corner has the threshold value, so that each point
+	// belongs to a single side
+	t := fmath.Clamp((threshold-valueA)/(valueB-valueA), isolineMinT, 1-isolineMinT)
+	return fgeom.Point{
+		X: float32(x+cornerA[0]) + t*float32(cornerB[0]-cornerA[0]),
+		Y: float32(y+cornerA[1]) + t*float32(cornerB[1]-cornerA[1]),
+	}
+}
+
+// ComputeIsolines chains the segments computed by ComputeIsolineSegments into polylines
+func ComputeIsolines(gridWidth, gridHeight int, value ScalarFieldCallback, threshold float32) []Isoline {
+	segments := ComputeIsolineSegments(gridWidth, gridHeight, value, threshold)
+	startingAt := make(map[fgeom.Point]int, len(segments))
+	endingAt := make(map[fgeom.Point]bool, len(segments))
+	for i, segment := range segments {
+		startingAt[segment.A] = i
+		endingAt[segment.B] = true
+	}
+
+	isolines := make([]Isoline, 0)
+	used := make([]bool, len(segments))
+	follow := func(first int) Isoline {
+		isoline := Isoline{Points: []fgeom.Point{segments[first].A}}
+		for current := first; ; {
+			used[current] = true
+			next, ok := startingAt[segments[current].B]
+			if !ok || (used[next] && next != first) {
+				isoline.Points = append(isoline.Points, segments[current].B)
+				return isoline
+			}
+			if next == first {
+				isoline.Closed = true
+				return isoline
+			}
+			isoline.Points = append(isoline.Points, segments[next].A)
+			current = next
+		}
+	}
+	// The open isolines start from a segment which doesn't continue another one
+	for i, segment := range segments {
+		if !used[i] && !endingAt[segment.A] {
+			isolines = append(isolines, follow(i))
+		}
+	}
+	for i := range segments {
+		if !used[i] {
+			isolines = append(isolines, follow(i))
+		}
+	}
+	return isolines
+}
@@ -0,0 +1,91 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/fgeom"
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"testing"
+)
+
+// fieldFromValues describes a field with a list of rows, one value for each point of the grid
+func fieldFromValues(rows [][]float32) (int, int, ScalarFieldCallback) {
+	return len(rows[0]), len(rows), func(x, y int) float32 { return rows[y][x] }
+}
+
+func assertSegmentsEqual(t *testing.T, text string, expected, received []fgeom.Segment) {
+	equal := len(expected) == len(received)
+	for i := 0; equal && i < len(expected); i++ {
+		equal = pointsClose(expected[i].A, received[i].A, 1e-4) && pointsClose(expected[i].B, received[i].B, 1e-4)
+	}
+	if !equal {
+		t.Errorf("%s failed\nexpected:\n%v\nreceived:\n%v", text, expected, received)
+	}
+}
+
+func TestComputeIsolineSegments(t *testing.T) {
+	var tests = []struct {
+		values   [][]float32
+		segments []fgeom.Segment
+	}{
+		{[][]float32{{0, 0}, {0, 0}}, []fgeom.Segment{}},
+		{[][]float32{{1, 1}, {1, 1}}, []fgeom.Segment{}},
+		// A single corner above the threshold
+		{[][]float32{{1, 0}, {0, 0}}, []fgeom.Segment{
+			{A: fgeom.Point{X: 0.5, Y: 0}, B: fgeom.Point{X: 0, Y: 0.5}},
+		}},
+		// The opposite case, the direction is reversed
+		{[][]float32{{0, 1}, {1, 1}}, []fgeom.Segment{
+			{A: fgeom.Point{X: 0, Y: 0.5}, B: fgeom.Point{X: 0.5, Y: 0}},
+		}},
+		// Saddle, with the center above the threshold
+		{[][]float32{{1, 0.2}, {0.2, 1}}, []fgeom.Segment{
+			{A: fgeom.Point{X: 0.625, Y: 0}, B: fgeom.Point{X: 1, Y: 0.375}},
+			{A: fgeom.Point{X: 0.375, Y: 1}, B: fgeom.Point{X: 0, Y: 0.625}},
+		}},
+		// Saddle, with the center below the threshold
+		{[][]float32{{0.6, 0}, {0, 0.6}}, []fgeom.Segment{
+			{A: fgeom.Point{X: 1.0 / 6, Y: 0}, B: fgeom.Point{X: 0, Y: 1.0 / 6}},
+			{A: fgeom.Point{X: 5.0 / 6, Y: 1}, B: fgeom.Point{X: 1, Y: 5.0 / 6}},
+		}},
+	}
+
+	for i, test := range tests {
+		w, h, value := fieldFromValues(test.values)
+		segments := ComputeIsolineSegments(w, h, value, 0.5)
+		assertSegmentsEqual(t, fmt.Sprintf("TestComputeIsolineSegments #%d", i), test.segments, segments)
+	}
+}
+
+func TestComputeIsolinesCircle(t *testing.T) {
+	const radius = 5
+	distance := func(x, y int) float32 {
+		return fmath.Sqrt(float32((x-8)*(x-8) + (y-8)*(y-8)))
+	}
+	isolines := ComputeIsolines(17, 17, func(x, y int) float32 { return radius - distance(x, y) }, 0)
+	if len(isolines) != 1 || !isolines[0].Closed {
+		t.Fatalf("TestComputeIsolinesCircle failed: expecting a closed isoline, received %v", isolines)
+	}
+
+	points := isolines[0].Points
+	area := float32(0)
+	for i, point := range points {
+		pointDistance := fmath.Sqrt((point.X-8)*(point.X-8) + (point.Y-8)*(point.Y-8))
+		if fmath.Abs(pointDistance-radius) > 0.1 {
+			t.Errorf("TestComputeIsolinesCircle failed: point %v is at distance %f", point, pointDistance)
+		}
+		next := points[(i+1)%len(points)]
+		area += point.X*next.Y - next.X*point.Y
+	}
+	// Clockwise, with the inside on the right
+	if area/2 < 3.1*radius*radius || area/2 > 3.15*radius*radius {
+		t.Errorf("TestComputeIsolinesCircle failed: area %f", area/2)
+	}
+}
+
+func TestComputeIsolinesOpen(t *testing.T) {
+	isolines := ComputeIsolines(6, 4, func(x, y int) float32 { return float32(x) }, 2.5)
+	expected := []Isoline{{Points: []fgeom.Point{{X: 2.5, Y: 3}, {X: 2.5, Y: 2}, {X: 2.5, Y: 1}, {X: 2.5, Y: 0}}}}
+	if fmt.Sprint(expected) != fmt.Sprint(isolines) {
+		t.Errorf("TestComputeIsolinesOpen failed\nexpected:\n%v\nreceived:\n%v", expected, isolines)
+	}
+}
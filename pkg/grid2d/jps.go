@@ -0,0 +1,171 @@
+package grid2d
+
+import (
+	"container/heap"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+	"math"
+)
+
+// Jump Point Search, as described by Daniel Harabor and Alban Grastien, in the variant which never cuts corners
+
+// FindPathJPS finds the shortest path from start to goal using Jump Point Search, which is much faster than A* on
+// large open grids. All the cells cost 1, and the moves are the same as FindPath with DefaultPathOptions: the cost
+// of the path found is the same.
+// It returns all the cells of the path, start and goal included, and its cost. It returns false if there's no path.
+func FindPathJPS(gridWidth, gridHeight int, isWalkable PathWalkableCallback, start, goal geom.Point) ([]geom.Point, float32, bool) {
+	grid := &pathGrid{width: gridWidth, height: gridHeight, isWalkable: isWalkable, options: DefaultPathOptions()}
+	if !grid.walkable(start.X, start.Y) || !grid.walkable(goal.X, goal.Y) {
+		return nil, 0, false
+	}
+
+	costs := make([]float32, gridWidth*gridHeight)
+	for i := range costs {
+		costs[i] = unreachableDistance
+	}
+	cameFrom := make([]int, gridWidth*gridHeight)
+	closed := make([]bool, gridWidth*gridHeight)
+
+	startIndex := start.Y*gridWidth + start.X
+	goalIndex := goal.Y*gridWidth + goal.X
+	costs[startIndex] = 0
+	cameFrom[startIndex] = -1
+	open := &pathQueue{}
+	heap.Push(open, pathQueueItem{index: startIndex, priority: grid.heuristic(start.X, start.Y, goal)})
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(pathQueueItem).index
+		if current == goalIndex {
+			return expandJumpPoints(buildPath(cameFrom, goalIndex, gridWidth)), costs[goalIndex], true
+		}
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+
+		x, y := current%gridWidth, current/gridWidth
+		parentX, parentY := x, y
+		if cameFrom[current] != -1 {
+			parentX, parentY = cameFrom[current]%gridWidth, cameFrom[current]/gridWidth
+		}
+		grid.forEachPrunedNeighbour(x, y, imath.Sign(x-parentX), imath.Sign(y-parentY), func(nx, ny int) {
+			jumpX, jumpY, found := grid.jump(nx, ny, nx-x, ny-y, goal)
+			if !found {
+				return
+			}
+			jumpPoint := jumpY*gridWidth + jumpX
+			cost := costs[current] + jumpCost(x, y, jumpX, jumpY)
+			if closed[jumpPoint] || cost >= costs[jumpPoint] {
+				return
+			}
+			costs[jumpPoint] = cost
+			cameFrom[jumpPoint] = current
+			heap.Push(open, pathQueueItem{index: jumpPoint, priority: cost + grid.heuristic(jumpX, jumpY, goal)})
+		})
+	}
+	return nil, 0, false
+}
+
+// forEachPrunedNeighbour calls the callback for the neighbours which can't be reached with a path of the same cost
+// avoiding the current cell. The direction is the one of the move which reached the cell, (0,0) for the start.
+func (g *pathGrid) forEachPrunedNeighbour(x, y, dx, dy int, callback func(nx, ny int)) {
+	if dx == 0 && dy == 0 {
+		g.forEachNeighbour(x, y, func(nx, ny int, diagonal bool) { callback(nx, ny) })
+		return
+	}
+	if dx != 0 && dy != 0 {
+		walkableX := g.walkable(x+dx, y)
+		walkableY := g.walkable(x, y+dy)
+		if walkableY {
+			callback(x, y+dy)
+		}
+		if walkableX {
+			callback(x+dx, y)
+		}
+		if walkableX && walkableY && g.walkable(x+dx, y+dy) {
+			callback(x+dx, y+dy)
+		}
+		return
+	}
+
+	// Moving straight, the perpendicular directions are the ones along which the obstacles can appear
+	perpendicularX, perpendicularY := dy, dx
+	walkableNext := g.walkable(x+dx, y+dy)
+	walkableA := g.walkable(x+perpendicularX, y+perpendicularY)
+	walkableB := g.walkable(x-perpendicularX, y-perpendicularY)
+	if walkableNext {
+		callback(x+dx, y+dy)
+		if walkableA && g.walkable(x+dx+perpendicularX, y+dy+perpendicularY) {
+			callback(x+dx+perpendicularX, y+dy+perpendicularY)
+		}
+		if walkableB && g.walkable(x+dx-perpendicularX, y+dy-perpendicularY) {
+			callback(x+dx-perpendicularX, y+dy-perpendicularY)
+		}
+	}
+	if walkableA {
+		callback(x+perpendicularX, y+perpendicularY)
+	}
+	if walkableB {
+		callback(x-perpendicularX, y-perpendicularY)
+	}
+}
+
+// jump moves from the cell in the given direction until it finds a jump point: the goal, or a cell from which the
+// path may need to change direction. It returns false if it reaches an obstacle first.
+func (g *pathGrid) jump(x, y, dx, dy int, goal geom.Point) (int, int, bool) {
+	for {
+		if !g.walkable(x, y) {
+			return 0, 0, false
+		}
+		if x == goal.X && y == goal.Y {
+			return x, y, true
+		}
+		if dx != 0 && dy != 0 {
+			if _, _, found := g.jump(x+dx, y, dx, 0, goal); found {
+				return x, y, true
+			}
+			if _, _, found := g.jump(x, y+dy, 0, dy, goal); found {
+				return x, y, true
+			}
+			// Diagonal moves can't cut corners
+			if !g.walkable(x+dx, y) || !g.walkable(x, y+dy) {
+				return 0, 0, false
+			}
+		} else if dx != 0 {
+			// An obstacle just passed, above or below, opens a new direction
+			if (g.walkable(x, y-1) && !g.walkable(x-dx, y-1)) || (g.walkable(x, y+1) && !g.walkable(x-dx, y+1)) {
+				return x, y, true
+			}
+		} else {
+			if (g.walkable(x-1, y) && !g.walkable(x-1, y-dy)) || (g.walkable(x+1, y) && !g.walkable(x+1, y-dy)) {
+				return x, y, true
+			}
+		}
+		x += dx
+		y += dy
+	}
+}
+
+// jumpCost returns the cost of going from a cell to a jump point, along a straight or diagonal line
+func jumpCost(x, y, jumpX, jumpY int) float32 {
+	dx := imath.Abs(jumpX - x)
+	dy := imath.Abs(jumpY - y)
+	if dx != 0 && dy != 0 {
+		return float32(dx) * math.Sqrt2
+	}
+	return float32(dx + dy)
+}
+
+// expandJumpPoints adds all the cells between each pair of consecutive jump points
+func expandJumpPoints(jumpPoints []geom.Point) []geom.Point {
+	path := []geom.Point{jumpPoints[0]}
+	for i := 1; i < len(jumpPoints); i++ {
+		from, to := jumpPoints[i-1], jumpPoints[i]
+		dx, dy := imath.Sign(to.X-from.X), imath.Sign(to.Y-from.Y)
+		for cell := from; cell != to; {
+			cell = geom.Point{X: cell.X + dx, Y: cell.Y + dy}
+			path = append(path, cell)
+		}
+	}
+	return path
+}
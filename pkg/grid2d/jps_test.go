@@ -0,0 +1,119 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+	"github.com/maxfish/go-libs/pkg/rand"
+	"testing"
+)
+
+// randomWalkableGrid creates a grid where about the given percentage of cells can't be crossed
+func randomWalkableGrid(gridWidth, gridHeight, obstacles int, seed uint32) PathWalkableCallback {
+	rng := rand.NewHashRngWithSeed(seed)
+	walkable := make([]bool, gridWidth*gridHeight)
+	for i := range walkable {
+		walkable[i] = !rng.Event(obstacles)
+	}
+	walkable[0] = true
+	walkable[len(walkable)-1] = true
+	return func(x, y int) bool { return walkable[y*gridWidth+x] }
+}
+
+func TestFindPathJPSMatchesFindPath(t *testing.T) {
+	const gridWidth, gridHeight = 32, 24
+	for seed := uint32(0); seed < 4; seed++ {
+		isWalkable := randomWalkableGrid(gridWidth, gridHeight, 10+int(seed)*8, seed)
+		rng := rand.NewHashRngWithSeed(seed)
+		for i := 0; i < 50; i++ {
+			start := geom.Point{X: int(rng.NextUint32LessThan(gridWidth)), Y: int(rng.NextUint32LessThan(gridHeight))}
+			goal := geom.Point{X: int(rng.NextUint32LessThan(gridWidth)), Y: int(rng.NextUint32LessThan(gridHeight))}
+			errorText := fmt.Sprintf("TestFindPathJPSMatchesFindPath seed:%d #%d %v->%v", seed, i, start, goal)
+
+			_, expectedCost, expectedFound := FindPath(gridWidth, gridHeight, isWalkable, start, goal, DefaultPathOptions())
+			path, cost, found := FindPathJPS(gridWidth, gridHeight, isWalkable, start, goal)
+			if found != expectedFound || fmath.Abs(cost-expectedCost) > 1e-3 {
+				t.Errorf("%s failed: cost %f found %v, expecting %f found %v", errorText, cost, found, expectedCost, expectedFound)
+				continue
+			}
+			if !found {
+				continue
+			}
+			assertValidPath(t, errorText, isWalkable, start, goal, path, cost)
+		}
+	}
+}
+
+// assertValidPath checks that the path is made of moves allowed by DefaultPathOptions, and that it has the given cost
+func assertValidPath(t *testing.T, errorText string, isWalkable PathWalkableCallback, start, goal geom.Point, path []geom.Point, cost float32) {
+	if path[0] != start || path[len(path)-1] != goal {
+		t.Errorf("%s failed: the path %v doesn't go from start to goal", errorText, path)
+		return
+	}
+	pathCost := float32(0)
+	for i := 1; i < len(path); i++ {
+		dx, dy := path[i].X-path[i-1].X, path[i].Y-path[i-1].Y
+		if imath.Abs(dx) > 1 || imath.Abs(dy) > 1 || !isWalkable(path[i].X, path[i].Y) ||
+			(dx != 0 && dy != 0 && (!isWalkable(path[i-1].X+dx, path[i-1].Y) || !isWalkable(path[i-1].X, path[i-1].Y+dy))) {
+			t.Errorf("%s failed: invalid move from %v to %v", errorText, path[i-1], path[i])
+			return
+		}
+		pathCost += jumpCost(0, 0, dx, dy)
+	}
+	if fmath.Abs(pathCost-cost) > 1e-3 {
+		t.Errorf("%s failed: the path costs %f, expecting %f", errorText, pathCost, cost)
+	}
+}
+
+// === Benchmarks
+
+// openMapGrid creates a large open map, scattered with rectangular obstacles
+func openMapGrid(gridSize, numObstacles int) PathWalkableCallback {
+	rng := rand.NewHashRngWithSeed(0)
+	walkable := make([]bool, gridSize*gridSize)
+	for i := range walkable {
+		walkable[i] = true
+	}
+	for i := 0; i < numObstacles; i++ {
+		w, h := int(rng.NextUint32InRange(2, 48)), int(rng.NextUint32InRange(2, 48))
+		x, y := int(rng.NextUint32LessThan(gridSize-w)), int(rng.NextUint32LessThan(gridSize-h))
+		for cellY := y; cellY < y+h; cellY++ {
+			for cellX := x; cellX < x+w; cellX++ {
+				walkable[cellY*gridSize+cellX] = false
+			}
+		}
+	}
+	walkable[0] = true
+	walkable[len(walkable)-1] = true
+	return func(x, y int) bool { return walkable[y*gridSize+x] }
+}
+
+func benchmarkPathfinder(b *testing.B, findPath func(gridWidth, gridHeight int, isWalkable PathWalkableCallback, start, goal geom.Point) ([]geom.Point, float32, bool)) {
+	const gridSize = 512
+	isWalkable := openMapGrid(gridSize, 150)
+	start := geom.Point{X: 0, Y: 0}
+	goal := geom.Point{X: gridSize - 1, Y: gridSize - 1}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, found := findPath(gridSize, gridSize, isWalkable, start, goal); !found {
+			b.Fatal("no path found")
+		}
+	}
+}
+
+// goos: linux
+// goarch: amd64
+// pkg: github.com/maxfish/go-libs/pkg/grid2d
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkFindPath512    	      25	  40133974 ns/op
+// BenchmarkFindPathJPS512 	      98	  12439535 ns/op
+func BenchmarkFindPath512(b *testing.B) {
+	benchmarkPathfinder(b, func(gridWidth, gridHeight int, isWalkable PathWalkableCallback, start, goal geom.Point) ([]geom.Point, float32, bool) {
+		return FindPath(gridWidth, gridHeight, isWalkable, start, goal, DefaultPathOptions())
+	})
+}
+
+func BenchmarkFindPathJPS512(b *testing.B) {
+	benchmarkPathfinder(b, FindPathJPS)
+}
@@ -0,0 +1,109 @@
+package grid2d
+
+import (
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+)
+
+// RegionInfo describes a region of connected cells
+type RegionInfo struct {
+	// The smallest rect containing all the cells of the region
+	Bounds    geom.Rect
+	CellCount int
+}
+
+// LabelRegions finds the regions of connected solid cells. When diagonals is true, the cells touching at a corner are
+// connected too.
+// It returns a grid with, for each solid cell, the index of its region (-1 for the other cells), and the info of each
+// region. The regions are sorted in the order in which their first cell is found, scanning the grid by rows.
+func LabelRegions(gridWidth, gridHeight int, isSolid ComputeEdgesCallback, diagonals bool) (*Grid[int], []RegionInfo) {
+	labels := NewGrid[int](gridWidth, gridHeight)
+	labels.Fill(-1)
+	cells := labels.Cells()
+	regions := make([]RegionInfo, 0)
+	visited := make([]bool, gridWidth*gridHeight)
+
+	for y := 0; y < gridHeight; y++ {
+		for x := 0; x < gridWidth; x++ {
+			if visited[y*gridWidth+x] || !isSolid(x, y) {
+				continue
+			}
+			label := len(regions)
+			minX, minY, maxX, maxY := x, y, x, y
+			count := scanlineFloodFill(gridWidth, gridHeight, x, y, diagonals, isSolid, visited, func(cellX, cellY int) {
+				cells[cellY*gridWidth+cellX] = label
+				minX, maxX = imath.Min(minX, cellX), imath.Max(maxX, cellX)
+				minY, maxY = imath.Min(minY, cellY), imath.Max(maxY, cellY)
+			})
+			regions = append(regions, RegionInfo{
+				Bounds:    geom.Rect{X: minX, Y: minY, W: maxX - minX + 1, H: maxY - minY + 1},
+				CellCount: count,
+			})
+		}
+	}
+	return labels, regions
+}
+
+// ScanlineFloodFill visits each cell matching the predicate, which is connected to the starting one through other
+// matching cells. When diagonals is true, the cells touching at a corner are connected too.
+// The cells are filled one horizontal span at a time. It returns the number of cells visited.
+func ScanlineFloodFill(gridWidth, gridHeight, x, y int, diagonals bool, matches func(x, y int) bool, visit func(x, y int)) int {
+	visited := make([]bool, gridWidth*gridHeight)
+	return scanlineFloodFill(gridWidth, gridHeight, x, y, diagonals, matches, visited, visit)
+}
+
+// scanlineFloodFill is ScanlineFloodFill, skipping the cells already visited by previous fills
+func scanlineFloodFill(gridWidth, gridHeight, x, y int, diagonals bool, matches func(x, y int) bool, visited []bool, visit func(x, y int)) int {
+	fillable := func(cellX, cellY int) bool {
+		return !visited[cellY*gridWidth+cellX] && matches(cellX, cellY)
+	}
+	if x < 0 || y < 0 || x >= gridWidth || y >= gridHeight {
+		return 0
+	}
+
+	count := 0
+	stack := []geom.Point{{X: x, Y: y}}
+	for len(stack) > 0 {
+		seed := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fillable(seed.X, seed.Y) {
+			continue
+		}
+
+		// Extends the span to the left and to the right
+		left, right := seed.X, seed.X
+		for left > 0 && fillable(left-1, seed.Y) {
+			left--
+		}
+		for right < gridWidth-1 && fillable(right+1, seed.Y) {
+			right++
+		}
+		for cellX := left; cellX <= right; cellX++ {
+			visited[seed.Y*gridWidth+cellX] = true
+			visit(cellX, seed.Y)
+		}
+		count += right - left + 1
+
+		// Adds a seed for each span in the rows above and below
+		if diagonals {
+			left, right = imath.Max(left-1, 0), imath.Min(right+1, gridWidth-1)
+		}
+		for _, cellY := range [2]int{seed.Y - 1, seed.Y + 1} {
+			if cellY < 0 || cellY >= gridHeight {
+				continue
+			}
+			inSpan := false
+			for cellX := left; cellX <= right; cellX++ {
+				if fillable(cellX, cellY) {
+					if !inSpan {
+						stack = append(stack, geom.Point{X: cellX, Y: cellY})
+						inSpan = true
+					}
+				} else {
+					inSpan = false
+				}
+			}
+		}
+	}
+	return count
+}
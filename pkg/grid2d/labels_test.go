@@ -0,0 +1,92 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"testing"
+)
+
+func TestLabelRegions(t *testing.T) {
+	grid := []string{
+		"##..#",
+		"#..##",
+		"..#..",
+		"##.##",
+	}
+	var tests = []struct {
+		diagonals bool
+		labels    []int
+		regions   []RegionInfo
+	}{
+		{false, []int{
+			0, 0, -1, -1, 1,
+			0, -1, -1, 1, 1,
+			-1, -1, 2, -1, -1,
+			3, 3, -1, 4, 4,
+		}, []RegionInfo{
+			{Bounds: geom.Rect{X: 0, Y: 0, W: 2, H: 2}, CellCount: 3},
+			{Bounds: geom.Rect{X: 3, Y: 0, W: 2, H: 2}, CellCount: 3},
+			{Bounds: geom.Rect{X: 2, Y: 2, W: 1, H: 1}, CellCount: 1},
+			{Bounds: geom.Rect{X: 0, Y: 3, W: 2, H: 1}, CellCount: 2},
+			{Bounds: geom.Rect{X: 3, Y: 3, W: 2, H: 1}, CellCount: 2},
+		}},
+		{true, []int{
+			0, 0, -1, -1, 1,
+			0, -1, -1, 1, 1,
+			-1, -1, 1, -1, -1,
+			1, 1, -1, 1, 1,
+		}, []RegionInfo{
+			{Bounds: geom.Rect{X: 0, Y: 0, W: 2, H: 2}, CellCount: 3},
+			{Bounds: geom.Rect{X: 0, Y: 0, W: 5, H: 4}, CellCount: 8},
+		}},
+	}
+
+	for i, test := range tests {
+		w, h, isSolid := gridFromStrings(grid)
+		labels, regions := LabelRegions(w, h, isSolid, test.diagonals)
+		testx.AssertEqual(t, fmt.Sprintf("TestLabelRegions labels #%d", i), test.labels, labels.Cells())
+		testx.AssertEqual(t, fmt.Sprintf("TestLabelRegions regions #%d", i), test.regions, regions)
+	}
+}
+
+func TestScanlineFloodFill(t *testing.T) {
+	// A spiral, the fill has to go back up and down several times
+	grid := []string{
+		".......",
+		".#####.",
+		".#...#.",
+		".#.#.#.",
+		".#.###.",
+		".#.....",
+		".######",
+	}
+	w, h := len(grid[0]), len(grid)
+	isEmpty := func(x, y int) bool { return grid[y][x] == '.' }
+	var tests = []struct {
+		x, y      int
+		diagonals bool
+		count     int
+	}{
+		{0, 0, false, 28},
+		{0, 0, true, 28},
+		{2, 2, false, 28},
+		{3, 2, false, 28},
+		{1, 1, false, 0},
+		{-1, 0, false, 0},
+	}
+	for i, test := range tests {
+		visited := make(map[geom.Point]int)
+		count := ScanlineFloodFill(w, h, test.x, test.y, test.diagonals, isEmpty, func(x, y int) {
+			visited[geom.Point{X: x, Y: y}]++
+		})
+		errorText := fmt.Sprintf("TestScanlineFloodFill #%d", i)
+		testx.AssertEqual(t, errorText, test.count, count)
+		for point, times := range visited {
+			if times != 1 || !isEmpty(point.X, point.Y) {
+				t.Errorf("%s failed: cell %v visited %d times", errorText, point, times)
+			}
+		}
+		testx.AssertEqual(t, errorText+" visited", test.count, len(visited))
+	}
+}
@@ -0,0 +1,294 @@
+package grid2d
+
+import (
+	"container/heap"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+	"math"
+)
+
+// PathWalkableCallback has to return 'true' for each cell which can be crossed
+type PathWalkableCallback func(x int, y int) bool
+
+// PathCostCallback returns the cost of entering a walkable cell
+type PathCostCallback func(x int, y int) float32
+
+type PathConnectivity int
+
+const (
+	// Moves only horizontally and vertically
+	PathConnectivity4 PathConnectivity = iota
+	// Moves diagonally too
+	PathConnectivity8
+)
+
+type PathDiagonalRule int
+
+const (
+	// A diagonal move is allowed only if both the cells it passes by are walkable
+	DiagonalNoCornerCutting PathDiagonalRule = iota
+	// A diagonal move is allowed if at least one of the cells it passes by is walkable
+	DiagonalOneWalkable
+	// A diagonal move is always allowed, even between two solid cells touching at a corner
+	DiagonalAlways
+)
+
+var unreachableDistance = float32(math.Inf(1))
+
+// PathOptions describes the moves allowed on the grid, and their cost
+type PathOptions struct {
+	Connectivity PathConnectivity
+	// Only used with PathConnectivity8
+	DiagonalRule PathDiagonalRule
+	// The cost of entering each cell, when nil all the cells cost 1. A diagonal move costs Sqrt(2) times the cost of
+	// the cell. For the heuristic of FindPath to find the cheapest path, the cost can't be lower than 1. FlowField.Path
+	// needs costs greater than 0.
+	Cost PathCostCallback
+}
+
+// DefaultPathOptions allows diagonal moves, without cutting corners, with all the cells costing 1
+func DefaultPathOptions() PathOptions {
+	return PathOptions{
+		Connectivity: PathConnectivity8,
+		DiagonalRule: DiagonalNoCornerCutting,
+	}
+}
+
+// pathGrid describes the grid and the moves allowed on it
+type pathGrid struct {
+	width, height int
+	isWalkable    PathWalkableCallback
+	options       PathOptions
+}
+
+func (g *pathGrid) walkable(x, y int) bool {
+	return x >= 0 && y >= 0 && x < g.width && y < g.height && g.isWalkable(x, y)
+}
+
+// moveCost returns the cost of moving from a cell to one of its neighbours
+func (g *pathGrid) moveCost(toX, toY int, diagonal bool) float32 {
+	cost := float32(1)
+	if g.options.Cost != nil {
+		cost = g.options.Cost(toX, toY)
+	}
+	if diagonal {
+		return cost * math.Sqrt2
+	}
+	return cost
+}
+
+// canMoveDiagonally checks the cells the diagonal move passes by. The rule is symmetric: the move is allowed in both
+// directions, or in none.
+func (g *pathGrid) canMoveDiagonally(x, y, dx, dy int) bool {
+	switch g.options.DiagonalRule {
+	case DiagonalNoCornerCutting:
+		return g.walkable(x+dx, y) && g.walkable(x, y+dy)
+	case DiagonalOneWalkable:
+		return g.walkable(x+dx, y) || g.walkable(x, y+dy)
+	default:
+		return true
+	}
+}
+
+var pathDirections = [8]geom.Point{{X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: 0, Y: -1}, {X: 1, Y: 1}, {X: -1, Y: 1}, {X: -1, Y: -1}, {X: 1, Y: -1}}
+
+// forEachNeighbour calls the callback for each walkable cell which can be reached from the cell with one move
+func (g *pathGrid) forEachNeighbour(x, y int, callback func(nx, ny int, diagonal bool)) {
+	numDirections := 4
+	if g.options.Connectivity == PathConnectivity8 {
+		numDirections = 8
+	}
+	for i := 0; i < numDirections; i++ {
+		dx, dy := pathDirections[i].X, pathDirections[i].Y
+		if !g.walkable(x+dx, y+dy) {
+			continue
+		}
+		diagonal := dx != 0 && dy != 0
+		if diagonal && !g.canMoveDiagonally(x, y, dx, dy) {
+			continue
+		}
+		callback(x+dx, y+dy, diagonal)
+	}
+}
+
+// heuristic estimates the cost of going from a cell to the goal, assuming all the cells cost 1
+func (g *pathGrid) heuristic(x, y int, goal geom.Point) float32 {
+	dx := imath.Abs(goal.X - x)
+	dy := imath.Abs(goal.Y - y)
+	if g.options.Connectivity == PathConnectivity4 {
+		return float32(dx + dy)
+	}
+	// Octile distance
+	return float32(imath.Max(dx, dy)-imath.Min(dx, dy)) + float32(imath.Min(dx, dy))*math.Sqrt2
+}
+
+// FindPath finds the cheapest path from start to goal using A*.
+// It returns the cells of the path, start and goal included, and its cost. It returns false if there's no path.
+func FindPath(gridWidth, gridHeight int, isWalkable PathWalkableCallback, start, goal geom.Point, options PathOptions) ([]geom.Point, float32, bool) {
+	grid := &pathGrid{width: gridWidth, height: gridHeight, isWalkable: isWalkable, options: options}
+	if !grid.walkable(start.X, start.Y) || !grid.walkable(goal.X, goal.Y) {
+		return nil, 0, false
+	}
+
+	costs := make([]float32, gridWidth*gridHeight)
+	for i := range costs {
+		costs[i] = unreachableDistance
+	}
+	cameFrom := make([]int, gridWidth*gridHeight)
+	closed := make([]bool, gridWidth*gridHeight)
+
+	startIndex := start.Y*gridWidth + start.X
+	goalIndex := goal.Y*gridWidth + goal.X
+	costs[startIndex] = 0
+	cameFrom[startIndex] = -1
+	open := &pathQueue{}
+	heap.Push(open, pathQueueItem{index: startIndex, priority: grid.heuristic(start.X, start.Y, goal)})
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(pathQueueItem).index
+		if current == goalIndex {
+			return buildPath(cameFrom, goalIndex, gridWidth), costs[goalIndex], true
+		}
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+
+		x, y := current%gridWidth, current/gridWidth
+		grid.forEachNeighbour(x, y, func(nx, ny int, diagonal bool) {
+			neighbour := ny*gridWidth + nx
+			cost := costs[current] + grid.moveCost(nx, ny, diagonal)
+			if closed[neighbour] || cost >= costs[neighbour] {
+				return
+			}
+			costs[neighbour] = cost
+			cameFrom[neighbour] = current
+			heap.Push(open, pathQueueItem{index: neighbour, priority: cost + grid.heuristic(nx, ny, goal)})
+		})
+	}
+	return nil, 0, false
+}
+
+// buildPath walks back from the last cell of the path to its start
+func buildPath(cameFrom []int, last int, gridWidth int) []geom.Point {
+	path := make([]geom.Point, 0)
+	for index := last; index != -1; index = cameFrom[index] {
+		path = append(path, geom.Point{X: index % gridWidth, Y: index / gridWidth})
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// FlowField contains, for each cell of the grid, the cost of reaching the target.
+// It's computed once with Dijkstra, and used by any number of agents heading to the same target.
+type FlowField struct {
+	grid      *pathGrid
+	target    geom.Point
+	distances []float32
+}
+
+// ComputeFlowField computes the cost of reaching the target from all the cells of the grid
+func ComputeFlowField(gridWidth, gridHeight int, isWalkable PathWalkableCallback, target geom.Point, options PathOptions) *FlowField {
+	grid := &pathGrid{width: gridWidth, height: gridHeight, isWalkable: isWalkable, options: options}
+	field := &FlowField{grid: grid, target: target, distances: make([]float32, gridWidth*gridHeight)}
+	for i := range field.distances {
+		field.distances[i] = unreachableDistance
+	}
+	if !grid.walkable(target.X, target.Y) {
+		return field
+	}
+
+	targetIndex := target.Y*gridWidth + target.X
+	field.distances[targetIndex] = 0
+	open := &pathQueue{}
+	heap.Push(open, pathQueueItem{index: targetIndex})
+	for open.Len() > 0 {
+		item := heap.Pop(open).(pathQueueItem)
+		current := item.index
+		if item.priority > field.distances[current] {
+			continue
+		}
+		x, y := current%gridWidth, current/gridWidth
+		// The moves are followed backwards: going from the neighbour to the current cell costs as entering the latter
+		grid.forEachNeighbour(x, y, func(nx, ny int, diagonal bool) {
+			neighbour := ny*gridWidth + nx
+			distance := field.distances[current] + grid.moveCost(x, y, diagonal)
+			if distance < field.distances[neighbour] {
+				field.distances[neighbour] = distance
+				heap.Push(open, pathQueueItem{index: neighbour, priority: distance})
+			}
+		})
+	}
+	return field
+}
+
+// Distance returns the cost of reaching the target from the cell, +Inf if the target can't be reached
+func (f *FlowField) Distance(x, y int) float32 {
+	if x < 0 || y < 0 || x >= f.grid.width || y >= f.grid.height {
+		return unreachableDistance
+	}
+	return f.distances[y*f.grid.width+x]
+}
+
+// Next returns the cell to move to, from the given one, to reach the target following the cheapest path.
+// It returns false if the target can't be reached, or if the cell is the target.
+func (f *FlowField) Next(x, y int) (geom.Point, bool) {
+	if f.Distance(x, y) == unreachableDistance || (x == f.target.X && y == f.target.Y) {
+		return geom.Point{}, false
+	}
+	best := geom.Point{}
+	bestDistance := unreachableDistance
+	f.grid.forEachNeighbour(x, y, func(nx, ny int, diagonal bool) {
+		distance := f.distances[ny*f.grid.width+nx] + f.grid.moveCost(nx, ny, diagonal)
+		if distance < bestDistance {
+			bestDistance = distance
+			best = geom.Point{X: nx, Y: ny}
+		}
+	})
+	return best, bestDistance != unreachableDistance
+}
+
+// Path follows the flow field from the given cell to the target.
+// It returns the cells of the path, the given one and the target included, or false if the target can't be reached.
+// The path stops where the distance from the target doesn't decrease, which can only happen with costs <= 0: in that
+// case the cells followed until then are returned, with false.
+func (f *FlowField) Path(x, y int) ([]geom.Point, bool) {
+	distance := f.Distance(x, y)
+	if distance == unreachableDistance {
+		return nil, false
+	}
+	path := []geom.Point{{X: x, Y: y}}
+	for {
+		next, ok := f.Next(x, y)
+		if !ok {
+			return path, true
+		}
+		nextDistance := f.Distance(next.X, next.Y)
+		if nextDistance >= distance {
+			return path, false
+		}
+		path = append(path, next)
+		x, y, distance = next.X, next.Y, nextDistance
+	}
+}
+
+type pathQueueItem struct {
+	index    int
+	priority float32
+}
+
+// pathQueue is a priority queue of cells, implementing heap.Interface
+type pathQueue []pathQueueItem
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathQueueItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,129 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/rand"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"math"
+	"testing"
+)
+
+// walkableFromStrings describes a grid with a list of rows, where '#' marks the cells which can't be crossed
+func walkableFromStrings(rows []string) (int, int, PathWalkableCallback) {
+	return len(rows[0]), len(rows), func(x, y int) bool { return rows[y][x] != '#' }
+}
+
+func TestFindPath(t *testing.T) {
+	maze := []string{
+		".#...",
+		".#.#.",
+		"...#.",
+		"####.",
+	}
+	var tests = []struct {
+		grid        []string
+		start, goal geom.Point
+		options     PathOptions
+		path        []geom.Point
+		cost        float32
+	}{
+		{maze, geom.Point{X: 0, Y: 0}, geom.Point{X: 4, Y: 3}, PathOptions{Connectivity: PathConnectivity4},
+			points(0, 0, 0, 1, 0, 2, 1, 2, 2, 2, 2, 1, 2, 0, 3, 0, 4, 0, 4, 1, 4, 2, 4, 3), 11},
+		// Without cutting corners, the walls of the maze prevent any diagonal move
+		{maze, geom.Point{X: 0, Y: 0}, geom.Point{X: 4, Y: 3}, DefaultPathOptions(),
+			points(0, 0, 0, 1, 0, 2, 1, 2, 2, 2, 2, 1, 2, 0, 3, 0, 4, 0, 4, 1, 4, 2, 4, 3), 11},
+		{maze, geom.Point{X: 0, Y: 0}, geom.Point{X: 4, Y: 3}, PathOptions{Connectivity: PathConnectivity8, DiagonalRule: DiagonalAlways},
+			points(0, 0, 0, 1, 1, 2, 2, 1, 3, 0, 4, 1, 4, 2, 4, 3), 3 + 4*math.Sqrt2},
+		// Cutting the corner of a solid cell
+		{[]string{"#.", ".."}, geom.Point{X: 0, Y: 1}, geom.Point{X: 1, Y: 0}, DefaultPathOptions(),
+			points(0, 1, 1, 1, 1, 0), 2},
+		{[]string{"#.", ".."}, geom.Point{X: 0, Y: 1}, geom.Point{X: 1, Y: 0}, PathOptions{Connectivity: PathConnectivity8, DiagonalRule: DiagonalOneWalkable},
+			points(0, 1, 1, 0), math.Sqrt2},
+		// Passing between two solid cells touching at a corner
+		{[]string{".#", "#."}, geom.Point{X: 0, Y: 0}, geom.Point{X: 1, Y: 1}, PathOptions{Connectivity: PathConnectivity8, DiagonalRule: DiagonalOneWalkable},
+			nil, 0},
+		{[]string{".#", "#."}, geom.Point{X: 0, Y: 0}, geom.Point{X: 1, Y: 1}, PathOptions{Connectivity: PathConnectivity8, DiagonalRule: DiagonalAlways},
+			points(0, 0, 1, 1), math.Sqrt2},
+		// The swamp is expensive to cross
+		{[]string{".....", ".~~~.", "#...#"}, geom.Point{X: 0, Y: 1}, geom.Point{X: 4, Y: 1}, PathOptions{Connectivity: PathConnectivity4},
+			points(0, 1, 0, 0, 1, 0, 2, 0, 3, 0, 4, 0, 4, 1), 6},
+		// Unreachable, or solid, goal
+		{[]string{".#."}, geom.Point{X: 0, Y: 0}, geom.Point{X: 2, Y: 0}, DefaultPathOptions(), nil, 0},
+		{[]string{".#."}, geom.Point{X: 0, Y: 0}, geom.Point{X: 1, Y: 0}, DefaultPathOptions(), nil, 0},
+		{[]string{".#."}, geom.Point{X: 0, Y: 0}, geom.Point{X: 0, Y: 0}, DefaultPathOptions(), points(0, 0), 0},
+	}
+
+	for i, test := range tests {
+		w, h, isWalkable := walkableFromStrings(test.grid)
+		options := test.options
+		options.Cost = func(x, y int) float32 {
+			if test.grid[y][x] == '~' {
+				return 5
+			}
+			return 1
+		}
+		path, cost, found := FindPath(w, h, isWalkable, test.start, test.goal, options)
+		errorText := fmt.Sprintf("TestFindPath #%d", i)
+		testx.AssertEqual(t, errorText, test.path, path)
+		if found != (test.path != nil) || fmath.Abs(cost-test.cost) > 1e-4 {
+			t.Errorf("%s failed: cost %f found %v, expecting %f", errorText, cost, found, test.cost)
+		}
+	}
+}
+
+func TestFlowFieldMatchesFindPath(t *testing.T) {
+	const gridWidth, gridHeight = 16, 12
+	rng := rand.NewHashRngWithSeed(0)
+	cells := make([]uint32, gridWidth*gridHeight)
+	for i := range cells {
+		cells[i] = rng.NextUint32LessThan(10)
+	}
+	isWalkable := func(x, y int) bool { return cells[y*gridWidth+x] > 1 }
+	target := geom.Point{X: 8, Y: 6}
+	cells[target.Y*gridWidth+target.X] = 2
+
+	for _, connectivity := range []PathConnectivity{PathConnectivity4, PathConnectivity8} {
+		options := PathOptions{
+			Connectivity: connectivity,
+			Cost:         func(x, y int) float32 { return float32(cells[y*gridWidth+x]) / 2 },
+		}
+		field := ComputeFlowField(gridWidth, gridHeight, isWalkable, target, options)
+		for y := 0; y < gridHeight; y++ {
+			for x := 0; x < gridWidth; x++ {
+				errorText := fmt.Sprintf("TestFlowFieldMatchesFindPath %d,%d connectivity:%d", x, y, connectivity)
+				_, cost, found := FindPath(gridWidth, gridHeight, isWalkable, geom.Point{X: x, Y: y}, target, options)
+				distance := field.Distance(x, y)
+				if !found {
+					if !math.IsInf(float64(distance), 1) {
+						t.Errorf("%s failed: distance %f, expecting unreachable", errorText, distance)
+					}
+					continue
+				}
+				if fmath.Abs(distance-cost) > 1e-3 {
+					t.Errorf("%s failed: distance %f, expecting %f", errorText, distance, cost)
+				}
+				// Following the field costs the same
+				path, ok := field.Path(x, y)
+				if !ok || path[len(path)-1] != target {
+					t.Errorf("%s failed: the path %v doesn't reach the target", errorText, path)
+				}
+			}
+		}
+	}
+}
+
+func TestFlowFieldZeroCost(t *testing.T) {
+	gridWidth, gridHeight, isWalkable := walkableFromStrings([]string{"....."})
+	options := PathOptions{Connectivity: PathConnectivity4, Cost: func(x, y int) float32 { return 0 }}
+	field := ComputeFlowField(gridWidth, gridHeight, isWalkable, geom.Point{X: 4, Y: 0}, options)
+
+	// All the cells are at distance 0 from the target, the path can't be followed
+	path, ok := field.Path(0, 0)
+	testx.AssertEqual(t, "TestFlowFieldZeroCost path", []geom.Point{{X: 0, Y: 0}}, path)
+	testx.AssertEqual(t, "TestFlowFieldZeroCost ok", false, ok)
+	path, ok = field.Path(4, 0)
+	testx.AssertEqual(t, "TestFlowFieldZeroCost target path", []geom.Point{{X: 4, Y: 0}}, path)
+	testx.AssertEqual(t, "TestFlowFieldZeroCost target ok", true, ok)
+}
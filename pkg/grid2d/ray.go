@@ -1,5 +1,7 @@
 package grid2d
 
+import "github.com/maxfish/go-libs/pkg/fmath"
+
 type GridRayCallback func(tileX, tileY int, x, y float32) bool
 
 func rayAxisSetup(cellSize int, pos, dir float32) (tile, deltaTile int, deltaDistance, ddt float32) {
@@ -48,3 +50,65 @@ func IterateRay(cellSize, gridWidth, gridHeight int, rayX, rayY, rayDirX, rayDir
 		}
 	}
 }
+
+// RaycastGridCallback has to return 'true' for each tile which stops the ray
+type RaycastGridCallback func(tileX, tileY int) bool
+
+// RaycastHit describes where a ray hits a tile
+type RaycastHit struct {
+	TileX, TileY int
+	// The point where the ray enters the tile
+	X, Y float32
+	// The normal of the side of the tile crossed by the ray. It's 0,0 when the ray starts inside the tile.
+	NormalX, NormalY int
+	// The distance traveled by the ray, in the same units as the coordinates
+	Distance float32
+}
+
+// RaycastGrid casts a ray through the grid, stopping at the first tile for which the callback returns true.
+// It returns false if no tile is hit within maxDistance, or before leaving the grid.
+func RaycastGrid(cellSize, gridWidth, gridHeight int, rayX, rayY, rayDirX, rayDirY, maxDistance float32, isSolid RaycastGridCallback) (RaycastHit, bool) {
+	length := fmath.Sqrt(rayDirX*rayDirX + rayDirY*rayDirY)
+	if length == 0 {
+		return RaycastHit{}, false
+	}
+	rayDirX /= length
+	rayDirY /= length
+
+	var distance float32
+	var normalX, normalY int
+	tileX, deltaTileX, deltaDistanceX, ddtX := rayAxisSetup(cellSize, rayX, rayDirX)
+	tileY, deltaTileY, deltaDistanceY, ddtY := rayAxisSetup(cellSize, rayY, rayDirY)
+
+	for tileX >= 0 && tileY >= 0 && tileX < gridWidth && tileY < gridHeight && distance <= maxDistance {
+		if isSolid(tileX, tileY) {
+			hit := RaycastHit{
+				TileX: tileX, TileY: tileY,
+				X: rayX + rayDirX*distance, Y: rayY + rayDirY*distance,
+				NormalX: normalX, NormalY: normalY,
+				Distance: distance,
+			}
+			// The coordinate of the crossed side is known exactly
+			if normalX != 0 {
+				hit.X = float32(cellSize * (tileX + (normalX+1)/2))
+			} else if normalY != 0 {
+				hit.Y = float32(cellSize * (tileY + (normalY+1)/2))
+			}
+			return hit, true
+		}
+		if deltaTileY == 0 || (deltaTileX != 0 && deltaDistanceX < deltaDistanceY) {
+			distance += deltaDistanceX
+			tileX += deltaTileX
+			deltaDistanceY -= deltaDistanceX
+			deltaDistanceX = ddtX
+			normalX, normalY = -deltaTileX, 0
+		} else {
+			distance += deltaDistanceY
+			tileY += deltaTileY
+			deltaDistanceX -= deltaDistanceY
+			deltaDistanceY = ddtY
+			normalX, normalY = 0, -deltaTileY
+		}
+	}
+	return RaycastHit{}, false
+}
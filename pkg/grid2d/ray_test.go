@@ -91,6 +91,41 @@ func TestIterateRayPoints(t *testing.T) {
 	}
 }
 
+func TestRaycastGrid(t *testing.T) {
+	var tests = []struct {
+		ray         [4]float32
+		maxDistance float32
+		hit         RaycastHit
+		found       bool
+	}{
+		{[4]float32{16, 48, 1, 0}, 1000, RaycastHit{TileX: 3, TileY: 1, X: 96, Y: 48, NormalX: -1, Distance: 80}, true},
+		{[4]float32{16, 48, 5, 0}, 80, RaycastHit{TileX: 3, TileY: 1, X: 96, Y: 48, NormalX: -1, Distance: 80}, true},
+		// The tile is too far
+		{[4]float32{16, 48, 1, 0}, 79, RaycastHit{}, false},
+		{[4]float32{112, 150, 0, -1}, 1000, RaycastHit{TileX: 3, TileY: 1, X: 112, Y: 64, NormalY: 1, Distance: 86}, true},
+		{[4]float32{150, 40, -1, 0}, 1000, RaycastHit{TileX: 3, TileY: 1, X: 128, Y: 40, NormalX: 1, Distance: 22}, true},
+		// Diagonal, crossing a row before the tile
+		{[4]float32{70, 80, 1, -1}, 1000, RaycastHit{TileX: 3, TileY: 1, X: 96, Y: 54, NormalX: -1, Distance: 36.76955}, true},
+		// Starting inside the tile
+		{[4]float32{100, 40, 1, 1}, 1000, RaycastHit{TileX: 3, TileY: 1, X: 100, Y: 40, Distance: 0}, true},
+		// Leaving the grid
+		{[4]float32{16, 16, 1, 0}, 1000, RaycastHit{}, false},
+		{[4]float32{16, 16, 0, 0}, 1000, RaycastHit{}, false},
+	}
+
+	isSolid := func(tileX, tileY int) bool { return tileX == 3 && tileY == 1 }
+	for index, test := range tests {
+		hit, found := RaycastGrid(32, 5, 5, test.ray[0], test.ray[1], test.ray[2], test.ray[3], test.maxDistance, isSolid)
+		// Rounds the coordinates to make the comparison easier
+		hit.Y = float32(int(hit.Y*1000+0.5)) / 1000
+		hit.Distance = float32(int(hit.Distance*1000+0.5)) / 1000
+		test.hit.Distance = float32(int(test.hit.Distance*1000+0.5)) / 1000
+		testIndex := fmt.Sprintf("%d", index)
+		testx.AssertEqual(t, "RaycastGrid() #"+testIndex, test.hit, hit)
+		testx.AssertEqual(t, "RaycastGrid() found #"+testIndex, test.found, found)
+	}
+}
+
 // === Benchmarks
 
 func setupRaysData(n int, gridSize int) [][]float32 {
@@ -0,0 +1,182 @@
+package grid2d
+
+import (
+	"github.com/maxfish/go-libs/pkg/fgeom"
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"math"
+)
+
+type TileCollision int
+
+const (
+	TileEmpty TileCollision = iota
+	TileSolid
+	// Blocks only what falls on it from above (with the Y axis pointing down)
+	TileOneWayPlatform
+)
+
+// TileCollisionCallback returns how the tile collides with the moving rect
+type TileCollisionCallback func(tileX, tileY int) TileCollision
+
+// Tolerance used to consider two touching sides as not overlapping, and to ignore tiny penetrations
+const sweepEpsilon = 1e-4
+
+// SweepResult is the outcome of moving a rect through the grid
+type SweepResult struct {
+	// The rect at its final position
+	Rect fgeom.Rect
+	// The normals of the tile sides touched during the movement, for each axis: -1, 0 or 1
+	NormalX, NormalY float32
+	// The fraction of the velocity at which the contact happened, for each axis. It's 1 if there was no contact.
+	TimeX, TimeY float32
+}
+
+// CollidedX checks if the rect has hit a tile while moving horizontally
+func (r SweepResult) CollidedX() bool { return r.NormalX != 0 }
+
+// CollidedY checks if the rect has hit a tile while moving vertically
+func (r SweepResult) CollidedY() bool { return r.NormalY != 0 }
+
+// SweepRect moves the rect by the velocity through the grid, stopping it against the solid tiles and sliding it along
+// their sides. The tiles outside the grid are empty. The one-way platforms only stop the rect if it was completely
+// above them at the start of the movement.
+func SweepRect(cellSize, gridWidth, gridHeight int, rect fgeom.Rect, velocityX, velocityY float32, tileAt TileCollisionCallback) SweepResult {
+	result := SweepResult{Rect: rect, TimeX: 1, TimeY: 1}
+	startBottom := rect.Bottom()
+	elapsed := float32(0)
+
+	// Each contact stops the movement along one axis, there can't be more than two
+	for i := 0; i < 2 && (velocityX != 0 || velocityY != 0); i++ {
+		time, normalX, normalY, tileSide := sweepRectStep(cellSize, gridWidth, gridHeight, result.Rect, velocityX, velocityY, startBottom, tileAt)
+		if normalX == 0 && normalY == 0 {
+			result.Rect = result.Rect.Translate(velocityX, velocityY)
+			return result
+		}
+		remaining := 1 - time
+		elapsed += time * (1 - elapsed)
+		if normalX != 0 {
+			// Places the rect exactly against the tile
+			result.Rect.Y += velocityY * time
+			if normalX < 0 {
+				result.Rect.X = tileSide - result.Rect.W
+			} else {
+				result.Rect.X = tileSide
+			}
+			result.NormalX, result.TimeX = normalX, elapsed
+			velocityX, velocityY = 0, velocityY*remaining
+		} else {
+			result.Rect.X += velocityX * time
+			if normalY < 0 {
+				result.Rect.Y = tileSide - result.Rect.H
+			} else {
+				result.Rect.Y = tileSide
+			}
+			result.NormalY, result.TimeY = normalY, elapsed
+			velocityX, velocityY = velocityX*remaining, 0
+		}
+	}
+	result.Rect = result.Rect.Translate(velocityX, velocityY)
+	return result
+}
+
+// sweepRectStep finds the first tile hit by the rect moving by the velocity.
+// It returns the time of the contact, the normal of the side hit and its coordinate. The normal is 0,0 if no tile is hit.
+func sweepRectStep(cellSize, gridWidth, gridHeight int, rect fgeom.Rect, velocityX, velocityY, startBottom float32, tileAt TileCollisionCallback) (float32, float32, float32, float32) {
+	cellSizeF := float32(cellSize)
+	minX := fmath.Min(rect.Left(), rect.Left()+velocityX)
+	maxX := fmath.Max(rect.Right(), rect.Right()+velocityX)
+	minY := fmath.Min(rect.Top(), rect.Top()+velocityY)
+	maxY := fmath.Max(rect.Bottom(), rect.Bottom()+velocityY)
+	startTileX := clampTile(fmath.Floor(minX/cellSizeF), gridWidth)
+	endTileX := clampTile(fmath.Floor(maxX/cellSizeF), gridWidth)
+	startTileY := clampTile(fmath.Floor(minY/cellSizeF), gridHeight)
+	endTileY := clampTile(fmath.Floor(maxY/cellSizeF), gridHeight)
+
+	bestTime := float32(1)
+	var bestNormalX, bestNormalY, bestSide float32
+	for tileY := startTileY; tileY <= endTileY; tileY++ {
+		for tileX := startTileX; tileX <= endTileX; tileX++ {
+			collision := tileAt(tileX, tileY)
+			if collision == TileEmpty {
+				continue
+			}
+			tile := fgeom.Rect{X: float32(tileX) * cellSizeF, Y: float32(tileY) * cellSizeF, W: cellSizeF, H: cellSizeF}
+			if collision == TileOneWayPlatform && (velocityY <= 0 || startBottom > tile.Top()+sweepEpsilon) {
+				continue
+			}
+			time, normalX, normalY, ok := sweepRectAgainstRect(rect, velocityX, velocityY, tile)
+			if !ok || time > bestTime || (time == bestTime && bestNormalY != 0) {
+				continue
+			}
+			if collision == TileOneWayPlatform && normalY >= 0 {
+				continue
+			}
+			bestTime, bestNormalX, bestNormalY = time, normalX, normalY
+			switch {
+			case normalX < 0:
+				bestSide = tile.Left()
+			case normalX > 0:
+				bestSide = tile.Right()
+			case normalY < 0:
+				bestSide = tile.Top()
+			default:
+				bestSide = tile.Bottom()
+			}
+		}
+	}
+	return bestTime, bestNormalX, bestNormalY, bestSide
+}
+
+// sweepRectAgainstRect computes when the moving rect starts touching the still one, and the normal of the side hit.
+// It returns false if they don't touch during the movement, or if they already overlap.
+func sweepRectAgainstRect(rect fgeom.Rect, velocityX, velocityY float32, other fgeom.Rect) (float32, float32, float32, bool) {
+	entryX, exitX, okX := sweepAxis(rect.Left(), rect.Right(), velocityX, other.Left(), other.Right())
+	entryY, exitY, okY := sweepAxis(rect.Top(), rect.Bottom(), velocityY, other.Top(), other.Bottom())
+	if !okX || !okY {
+		return 0, 0, 0, false
+	}
+	entry := fmath.Max(entryX, entryY)
+	exit := fmath.Min(exitX, exitY)
+	if entry >= exit || entry > 1 || entry < 0 {
+		return 0, 0, 0, false
+	}
+	// The axis entering last is the one touched. When both enter together, the rect lands on the corner.
+	if entryX > entryY {
+		return entry, -fmath.Sign(velocityX), 0, true
+	}
+	return entry, 0, -fmath.Sign(velocityY), true
+}
+
+// sweepAxis computes the interval of time, as a fraction of the velocity, during which the two segments overlap.
+// The segments only touching at their ends don't overlap.
+func sweepAxis(min, max, velocity, otherMin, otherMax float32) (float32, float32, bool) {
+	if velocity == 0 {
+		if max > otherMin+sweepEpsilon && min < otherMax-sweepEpsilon {
+			return float32(math.Inf(-1)), float32(math.Inf(1)), true
+		}
+		return 0, 0, false
+	}
+	var entry, exit float32
+	if velocity > 0 {
+		entry = (otherMin - max) / velocity
+		exit = (otherMax - min) / velocity
+	} else {
+		entry = (otherMax - min) / velocity
+		exit = (otherMin - max) / velocity
+	}
+	// Tiny penetrations, due to rounding, are resolved as contacts
+	if entry < 0 && entry*fmath.Abs(velocity) > -sweepEpsilon {
+		entry = 0
+	}
+	return entry, exit, true
+}
+
+func clampTile(tile, gridSize int) int {
+	if tile < 0 {
+		return 0
+	}
+	if tile >= gridSize {
+		return gridSize - 1
+	}
+	return tile
+}
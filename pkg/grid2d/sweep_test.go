@@ -0,0 +1,76 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/fgeom"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"testing"
+)
+
+// tilesFromStrings describes a grid with a list of rows, where '#' marks the solid tiles and '-' the one-way platforms
+func tilesFromStrings(rows []string) (int, int, TileCollisionCallback) {
+	return len(rows[0]), len(rows), func(x, y int) TileCollision {
+		switch rows[y][x] {
+		case '#':
+			return TileSolid
+		case '-':
+			return TileOneWayPlatform
+		default:
+			return TileEmpty
+		}
+	}
+}
+
+func TestSweepRect(t *testing.T) {
+	room := []string{
+		"......",
+		"......",
+		"....#.",
+		"..--#.",
+		"......",
+		"######",
+	}
+	var tests = []struct {
+		rect                 fgeom.Rect
+		velocityX, velocityY float32
+		result               SweepResult
+	}{
+		// Free movement
+		{fgeom.Rect{X: 4, Y: 4, W: 8, H: 8}, 20, 10, SweepResult{Rect: fgeom.Rect{X: 24, Y: 14, W: 8, H: 8}, TimeX: 1, TimeY: 1}},
+		// Landing on the floor
+		{fgeom.Rect{X: 16, Y: 60, W: 8, H: 8}, 0, 30, SweepResult{Rect: fgeom.Rect{X: 16, Y: 72, W: 8, H: 8}, NormalY: -1, TimeX: 1, TimeY: 0.4}},
+		// Landing while moving sideways, then sliding on the floor
+		{fgeom.Rect{X: 16, Y: 68, W: 8, H: 8}, 20, 10, SweepResult{Rect: fgeom.Rect{X: 36, Y: 72, W: 8, H: 8}, NormalY: -1, TimeX: 1, TimeY: 0.4}},
+		// Walking on the floor, over the borders between the tiles
+		{fgeom.Rect{X: 4, Y: 72, W: 8, H: 8}, 60, 0, SweepResult{Rect: fgeom.Rect{X: 64, Y: 72, W: 8, H: 8}, TimeX: 1, TimeY: 1}},
+		// Hitting a wall, then sliding along it
+		{fgeom.Rect{X: 48, Y: 20, W: 8, H: 8}, 16, 16, SweepResult{Rect: fgeom.Rect{X: 56, Y: 36, W: 8, H: 8}, NormalX: -1, TimeX: 0.5, TimeY: 1}},
+		// Hitting a wall, then sliding down until landing on a platform
+		{fgeom.Rect{X: 48, Y: 20, W: 8, H: 8}, 16, 24, SweepResult{Rect: fgeom.Rect{X: 56, Y: 40, W: 8, H: 8}, NormalX: -1, NormalY: -1, TimeX: 0.5, TimeY: 0.83}},
+		// Hitting the ceiling of the wall
+		{fgeom.Rect{X: 66, Y: 64, W: 8, H: 8}, 0, -20, SweepResult{Rect: fgeom.Rect{X: 66, Y: 64, W: 8, H: 8}, NormalY: 1, TimeX: 1, TimeY: 0}},
+		// Too fast to stop, the wall can't be crossed anyway
+		{fgeom.Rect{X: 4, Y: 36, W: 8, H: 8}, 200, 0, SweepResult{Rect: fgeom.Rect{X: 56, Y: 36, W: 8, H: 8}, NormalX: -1, TimeX: 0.26, TimeY: 1}},
+		// Hitting exactly the corner of the wall, the rect lands on it
+		{fgeom.Rect{X: 48, Y: 16, W: 8, H: 8}, 16, 16, SweepResult{Rect: fgeom.Rect{X: 64, Y: 24, W: 8, H: 8}, NormalY: -1, TimeX: 1, TimeY: 0.5}},
+		// Resting on the floor, the contact is reported at the end of the movement
+		{fgeom.Rect{X: 16, Y: 62, W: 8, H: 8}, 0, 10, SweepResult{Rect: fgeom.Rect{X: 16, Y: 72, W: 8, H: 8}, NormalY: -1, TimeX: 1, TimeY: 1}},
+		// Landing on a one-way platform
+		{fgeom.Rect{X: 36, Y: 30, W: 8, H: 8}, 0, 20, SweepResult{Rect: fgeom.Rect{X: 36, Y: 40, W: 8, H: 8}, NormalY: -1, TimeX: 1, TimeY: 0.5}},
+		// Jumping through a one-way platform from below
+		{fgeom.Rect{X: 36, Y: 66, W: 8, H: 8}, 0, -40, SweepResult{Rect: fgeom.Rect{X: 36, Y: 26, W: 8, H: 8}, TimeX: 1, TimeY: 1}},
+		// Falling through a one-way platform, when not completely above it
+		{fgeom.Rect{X: 36, Y: 44, W: 8, H: 8}, 0, 10, SweepResult{Rect: fgeom.Rect{X: 36, Y: 54, W: 8, H: 8}, TimeX: 1, TimeY: 1}},
+		// Walking into the side of a one-way platform
+		{fgeom.Rect{X: 20, Y: 52, W: 8, H: 8}, 20, 0, SweepResult{Rect: fgeom.Rect{X: 40, Y: 52, W: 8, H: 8}, TimeX: 1, TimeY: 1}},
+	}
+
+	w, h, tileAt := tilesFromStrings(room)
+	for i, test := range tests {
+		result := SweepRect(16, w, h, test.rect, test.velocityX, test.velocityY, tileAt)
+		// Rounds the times to make the comparison easier
+		result.TimeX = float32(int(result.TimeX*100+0.5)) / 100
+		result.TimeY = float32(int(result.TimeY*100+0.5)) / 100
+		testx.AssertEqual(t, fmt.Sprintf("TestSweepRect #%d", i), test.result, result)
+	}
+}
@@ -0,0 +1,236 @@
+package grid2d
+
+import (
+	"github.com/maxfish/go-libs/pkg/fgeom"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"math"
+	"sort"
+)
+
+const visibilityEpsilon = 1e-9
+
+// visibilitySegment is a segment relative to the viewer, with the angles of its endpoints in clockwise order
+type visibilitySegment struct {
+	ax, ay, bx, by         float64
+	startAngle, angleRange float64
+}
+
+// visibilityEvent is the start or the end of a segment, during the angular sweep
+type visibilityEvent struct {
+	angle   float64
+	segment int
+	start   bool
+}
+
+// ComputeVisibilityPolygon returns the area visible from the viewer, blocked by the segments (e.g. the ones computed
+// by ComputeEdges). The area is bounded by a square, not a circle: the axis-aligned square of side 2*halfSize centered
+// on the viewer. Where nothing blocks the view, the polygon follows the sides of the square.
+// The points of the polygon are sorted by angle, clockwise with the Y axis pointing down, starting from the left.
+// The segments are expected not to cross each other, but they can share their endpoints.
+func ComputeVisibilityPolygon(viewerX, viewerY, halfSize float32, segments []*geom.Segment) []fgeom.Point {
+	r := float64(halfSize)
+	visibilitySegments := make([]visibilitySegment, 0, len(segments)+4)
+	for _, segment := range segments {
+		ax, ay := float64(segment.A.X)-float64(viewerX), float64(segment.A.Y)-float64(viewerY)
+		bx, by := float64(segment.B.X)-float64(viewerX), float64(segment.B.Y)-float64(viewerY)
+		if !clipSegmentToSquare(&ax, &ay, &bx, &by, r) {
+			continue
+		}
+		visibilitySegments = appendVisibilitySegment(visibilitySegments, ax, ay, bx, by)
+	}
+	// The bounds of the area
+	visibilitySegments = appendVisibilitySegment(visibilitySegments, -r, -r, r, -r)
+	visibilitySegments = appendVisibilitySegment(visibilitySegments, r, -r, r, r)
+	visibilitySegments = appendVisibilitySegment(visibilitySegments, r, r, -r, r)
+	visibilitySegments = appendVisibilitySegment(visibilitySegments, -r, r, -r, -r)
+
+	events := make([]visibilityEvent, 0, len(visibilitySegments)*2)
+	for i, segment := range visibilitySegments {
+		events = append(events, visibilityEvent{angle: segment.startAngle, segment: i, start: true})
+		events = append(events, visibilityEvent{angle: normalizeAngle(segment.startAngle + segment.angleRange), segment: i})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].angle < events[j].angle })
+
+	// Groups the events happening at the same angle, the sweep stops only once for each of them
+	angles := make([]float64, 0)
+	groups := make([][]visibilityEvent, 0)
+	for _, event := range events {
+		if len(angles) == 0 || event.angle-angles[len(angles)-1] > visibilityEpsilon {
+			angles = append(angles, event.angle)
+			groups = append(groups, make([]visibilityEvent, 0, 2))
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], event)
+	}
+
+	// The segments crossing the first interval are found directly, then the sweep keeps the list updated
+	open := make([]int, 0)
+	firstMiddle := angles[0] + intervalLength(angles, 0)/2
+	for i, segment := range visibilitySegments {
+		if segment.covers(firstMiddle) {
+			open = append(open, i)
+		}
+	}
+
+	points := make([]fgeom.Point, 0, len(angles)*2)
+	for i := range angles {
+		if i > 0 {
+			for _, event := range groups[i] {
+				if event.start {
+					open = append(open, event.segment)
+				} else {
+					open = removeOpenSegment(open, event.segment)
+				}
+			}
+		}
+
+		// Between two consecutive angles the same segment is the closest to the viewer
+		startAngle := angles[i]
+		endAngle := startAngle + intervalLength(angles, i)
+		middle := (startAngle + endAngle) / 2
+		closest := -1
+		closestDistance := math.MaxFloat64
+		for _, index := range open {
+			distance := visibilitySegments[index].rayDistance(middle)
+			if distance < closestDistance {
+				closestDistance = distance
+				closest = index
+			}
+		}
+		if closest == -1 {
+			continue
+		}
+		for _, angle := range []float64{startAngle, endAngle} {
+			distance := visibilitySegments[closest].rayDistance(angle)
+			points = append(points, fgeom.Point{
+				X: viewerX + float32(math.Cos(angle)*distance),
+				Y: viewerY + float32(math.Sin(angle)*distance),
+			})
+		}
+	}
+	return simplifyPolygon(points)
+}
+
+// appendVisibilitySegment adds the segment to the list, unless it's aligned with the viewer and can't block the view
+func appendVisibilitySegment(segments []visibilitySegment, ax, ay, bx, by float64) []visibilitySegment {
+	if math.Abs(ax*by-ay*bx) < visibilityEpsilon {
+		return segments
+	}
+	angleA := math.Atan2(ay, ax)
+	angleB := math.Atan2(by, bx)
+	angleRange := normalizeAngle(angleB - angleA)
+	if angleRange < 0 {
+		return append(segments, visibilitySegment{ax: ax, ay: ay, bx: bx, by: by, startAngle: angleB, angleRange: -angleRange})
+	}
+	return append(segments, visibilitySegment{ax: ax, ay: ay, bx: bx, by: by, startAngle: angleA, angleRange: angleRange})
+}
+
+// covers checks if the ray at the given angle crosses the segment, excluding its endpoints
+func (s *visibilitySegment) covers(angle float64) bool {
+	delta := normalizeAngle(angle - s.startAngle)
+	if delta < 0 {
+		delta += 2 * math.Pi
+	}
+	return delta > 0 && delta < s.angleRange
+}
+
+// rayDistance returns the distance from the viewer at which the ray with the given angle hits the line of the segment
+func (s *visibilitySegment) rayDistance(angle float64) float64 {
+	dirX, dirY := math.Cos(angle), math.Sin(angle)
+	segmentX, segmentY := s.bx-s.ax, s.by-s.ay
+	denominator := dirX*segmentY - dirY*segmentX
+	if denominator == 0 {
+		return math.MaxFloat64
+	}
+	return (s.ax*segmentY - s.ay*segmentX) / denominator
+}
+
+// intervalLength returns the angle between the i-th angle and the next one, wrapping around after the last one
+func intervalLength(angles []float64, i int) float64 {
+	if i == len(angles)-1 {
+		return angles[0] + 2*math.Pi - angles[i]
+	}
+	return angles[i+1] - angles[i]
+}
+
+// normalizeAngle brings the angle in the range [-Pi, Pi)
+func normalizeAngle(angle float64) float64 {
+	for angle >= math.Pi {
+		angle -= 2 * math.Pi
+	}
+	for angle < -math.Pi {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
+
+func removeOpenSegment(open []int, segment int) []int {
+	for i, index := range open {
+		if index == segment {
+			return append(open[:i], open[i+1:]...)
+		}
+	}
+	return open
+}
+
+// clipSegmentToSquare clips the segment to the square of side 2*r centered on the origin (Liang-Barsky).
+// It returns false if the segment is completely outside.
+func clipSegmentToSquare(ax, ay, bx, by *float64, r float64) bool {
+	dx, dy := *bx-*ax, *by-*ay
+	t0, t1 := 0.0, 1.0
+	for _, edge := range [][2]float64{{-dx, *ax + r}, {dx, r - *ax}, {-dy, *ay + r}, {dy, r - *ay}} {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+		if t0 > t1 {
+			return false
+		}
+	}
+	*ax, *ay, *bx, *by = *ax+t0*dx, *ay+t0*dy, *ax+t1*dx, *ay+t1*dy
+	return true
+}
+
+// simplifyPolygon removes the repeated points, and the ones lying on the line between their neighbours
+func simplifyPolygon(points []fgeom.Point) []fgeom.Point {
+	const epsilon = 1e-4
+	result := make([]fgeom.Point, 0, len(points))
+	for _, point := range points {
+		if len(result) > 0 && pointsClose(result[len(result)-1], point, epsilon) {
+			continue
+		}
+		result = append(result, point)
+	}
+	for len(result) > 1 && pointsClose(result[0], result[len(result)-1], epsilon) {
+		result = result[:len(result)-1]
+	}
+
+	for removed := true; removed && len(result) > 3; {
+		removed = false
+		for i := 0; i < len(result) && len(result) > 3; i++ {
+			previous := result[(i+len(result)-1)%len(result)]
+			next := result[(i+1)%len(result)]
+			current := result[i]
+			cross := (current.X-previous.X)*(next.Y-current.Y) - (current.Y-previous.Y)*(next.X-current.X)
+			dot := (current.X-previous.X)*(next.X-current.X) + (current.Y-previous.Y)*(next.Y-current.Y)
+			if cross > -epsilon && cross < epsilon && dot > 0 {
+				result = append(result[:i], result[i+1:]...)
+				removed = true
+				i--
+			}
+		}
+	}
+	return result
+}
+
+func pointsClose(a, b fgeom.Point, epsilon float32) bool {
+	return a.X-b.X < epsilon && b.X-a.X < epsilon && a.Y-b.Y < epsilon && b.Y-a.Y < epsilon
+}
@@ -0,0 +1,65 @@
+package grid2d
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/fgeom"
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"testing"
+)
+
+func assertPolygonEqual(t *testing.T, text string, expected, received []fgeom.Point) {
+	equal := len(expected) == len(received)
+	for i := 0; equal && i < len(expected); i++ {
+		equal = fmath.Abs(expected[i].X-received[i].X) < 1e-3 && fmath.Abs(expected[i].Y-received[i].Y) < 1e-3
+	}
+	if !equal {
+		t.Errorf("%s failed\nexpected:\n%v\nreceived:\n%v", text, expected, received)
+	}
+}
+
+func TestComputeVisibilityPolygon(t *testing.T) {
+	var tests = []struct {
+		grid             []string
+		viewerX, viewerY float32
+		halfSize         float32
+		polygon          []fgeom.Point
+	}{
+		// Nothing blocks the view
+		{[]string{"..."}, 1.5, 0.5, 2, []fgeom.Point{{X: -0.5, Y: -1.5}, {X: 3.5, Y: -1.5}, {X: 3.5, Y: 2.5}, {X: -0.5, Y: 2.5}}},
+		// Closed room, the corners where the walls meet don't leak
+		{[]string{"#####", "#...#", "#...#", "#...#", "#####"}, 2.5, 2.5, 10, []fgeom.Point{
+			{X: 1, Y: 1}, {X: 4, Y: 1}, {X: 4, Y: 4}, {X: 1, Y: 4},
+		}},
+		// Viewer off center, facing the corner of a single cell
+		{[]string{"...", ".#.", "..."}, 0.5, 0.5, 2, []fgeom.Point{
+			{X: -1.5, Y: -1.5}, {X: 2.5, Y: -1.5}, {X: 2.5, Y: 1.1666}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2},
+			{X: 1.1666, Y: 2.5}, {X: -1.5, Y: 2.5},
+		}},
+		// Segments partially outside the area are clipped
+		{[]string{"......", "......", "######"}, 0.5, 0.5, 1, []fgeom.Point{{X: -0.5, Y: -0.5}, {X: 1.5, Y: -0.5}, {X: 1.5, Y: 1.5}, {X: -0.5, Y: 1.5}}},
+		{[]string{"......", "......", "######"}, 0.5, 0.5, 4, []fgeom.Point{{X: -3.5, Y: -3.5}, {X: 4.5, Y: -3.5}, {X: 4.5, Y: 2}, {X: 0, Y: 2}, {X: -0.8333, Y: 4.5}, {X: -3.5, Y: 4.5}}},
+	}
+
+	for i, test := range tests {
+		w, h, isSolid := gridFromStrings(test.grid)
+		polygon := ComputeVisibilityPolygon(test.viewerX, test.viewerY, test.halfSize, ComputeEdges(w, h, isSolid))
+		assertPolygonEqual(t, fmt.Sprintf("TestComputeVisibilityPolygon #%d", i), test.polygon, polygon)
+	}
+}
+
+func TestComputeVisibilityPolygonDiagonalGap(t *testing.T) {
+	// Two cells touching at a corner: the view can't pass between them
+	w, h, isSolid := gridFromStrings([]string{"#..", ".#.", "..."})
+	polygon := ComputeVisibilityPolygon(1.5, 0.5, 3, ComputeEdges(w, h, isSolid))
+
+	touchesCorner := false
+	for _, point := range polygon {
+		if point.X < 1-1e-3 && point.Y > 1+1e-3 {
+			t.Errorf("TestComputeVisibilityPolygonDiagonalGap failed: point %v is behind the corner", point)
+		}
+		touchesCorner = touchesCorner || (fmath.Abs(point.X-1) < 1e-3 && fmath.Abs(point.Y-1) < 1e-3)
+	}
+	if !touchesCorner {
+		t.Errorf("TestComputeVisibilityPolygonDiagonalGap failed: the polygon doesn't reach the corner\n%v", polygon)
+	}
+}
@@ -1,79 +1,521 @@
 package imagex
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+	"hash/fnv"
 	"image"
 	"image/draw"
+	"runtime"
+	"sync"
 )
 
-// BuildAtlas generates an atlas image and returns it. It also returns info on how the rects have been packed
+// AtlasOptions controls how BuildAtlasWithOptions searches for the best packing
+type AtlasOptions struct {
+	PaddingX, PaddingY int
+	AllowRotation      bool
+	// Widths tried for the atlas, the height is left to the algorithm
+	Widths []int
+	// PowerOfTwo rounds the width and the height of the atlas up to a power of two
+	PowerOfTwo bool
+	// Square forces the atlas to have the same width and height
+	Square bool
+	// MaxWidth and MaxHeight limit the size of the atlas, 0 means no limit
+	MaxWidth, MaxHeight int
+	// Methods are the MaxRects heuristics tried for each of the widths
+	Methods []geom.FreeRectChoiceHeuristic
+	// Packers, when not empty, are tried for each of the widths instead of the MaxRects heuristics listed in Methods.
+	// They can be called concurrently.
+	Packers []AtlasPackerFunc
+	// GrowthStep is how much the area is increased, as a fraction of the area of the images, when they don't fit.
+	// With a value <= 0 only the area of the images is tried.
+	GrowthStep float32
+	// Trim removes the transparent borders of the images before packing them
+	Trim bool
+	// Deduplicate packs identical images only once, all the copies share the same rect
+	Deduplicate bool
+	// Extrude places each image in the middle of its padding, and fills the padding on all the sides repeating the edge
+	// pixels, instead of leaving it transparent. This avoids bleeding when the atlas is sampled with bilinear filtering.
+	// The padding should be at least 2 pixels, so that each side gets at least one.
+	Extrude bool
+	// Workers is the number of packings evaluated concurrently, 0 means one for each CPU.
+	// The result doesn't depend on the number of workers.
+	Workers int
+}
+
+// AtlasResult describes the packing chosen for an atlas
+type AtlasResult struct {
+	Nodes     []geom.RectNode              // How the rects have been packed
+	Width     int                          // Width of the atlas image
+	Height    int                          // Height of the atlas image
+	Occupancy float32                      // Ratio of the atlas area covered by the rects
+	Method    geom.FreeRectChoiceHeuristic // Heuristic used to pack the rects, when AtlasOptions.Packers is empty
+	Packer    int                          // Index of the AtlasOptions.Packers used to pack the rects
+	Sprites   []AtlasSprite                // How each of the input images has been stored, in the same order
+	PaddingX  int                          // Horizontal padding included in the size of the nodes
+	PaddingY  int                          // Vertical padding included in the size of the nodes
+	OffsetX   int                          // Horizontal position of the images inside their nodes
+	OffsetY   int                          // Vertical position of the images inside their nodes
+}
+
+// AtlasSprite describes how one of the input images has been stored in the atlas.
+// When the images are deduplicated, the copies of an image share the RectNode of the one which has been packed.
+type AtlasSprite struct {
+	geom.RectNode             // Where the image, trimmed if requested, has been placed, padding and offset included
+	Trim          geom.Insets // Transparent borders removed from the image
+	SourceSize    geom.Size   // Size of the image before trimming
+}
+
+// AtlasPackerFunc creates the packer used to fill an atlas of the given size
+type AtlasPackerFunc func(width, height int, options AtlasOptions) geom.BinPacker
+
+// atlasPacker is one of the packers tried while searching for the best packing
+type atlasPacker struct {
+	newPacker AtlasPackerFunc
+	method    geom.FreeRectChoiceHeuristic
+	index     int
+}
+
+// AtlasPageNode describes where an image has been placed in a multi-page atlas
+type AtlasPageNode struct {
+	geom.RectNode
+	Page int // Index of the page containing the image
+}
+
+// defaultHeuristics are the MaxRects heuristics tried by default. The slower ones, like RectContactPointRule, can be
+// added through AtlasOptions.Methods.
+var defaultHeuristics = []geom.FreeRectChoiceHeuristic{
+	geom.RectBestShortSideFit, geom.RectBestAreaFit, geom.RectBottomLeftRule,
+}
+
+// DefaultAtlasOptions returns the options used by BuildAtlas
+func DefaultAtlasOptions() AtlasOptions {
+	return AtlasOptions{
+		Widths:     []int{128, 256, 512, 1024},
+		Methods:    append([]geom.FreeRectChoiceHeuristic{}, defaultHeuristics...),
+		GrowthStep: 0.01,
+	}
+}
+
+// BuildAtlas generates an atlas image and returns it. It also returns info on how the rects have been packed.
+// It returns nil if the images cannot be packed with the default options.
 func BuildAtlas(images []*image.RGBA, paddingX, paddingY int, allowRotation bool) (*image.RGBA, []geom.RectNode) {
-	result, _ := bruteForceAtlasPacking(images, paddingX, paddingY, allowRotation)
+	options := DefaultAtlasOptions()
+	options.PaddingX = paddingX
+	options.PaddingY = paddingY
+	options.AllowRotation = allowRotation
 
-	atlasImage := image.NewRGBA(image.Rect(0, 0, result.Width, result.Height))
-	for _, node := range result.PlacedRects {
+	atlasImage, result, err := BuildAtlasWithOptions(images, options)
+	if err != nil {
+		return nil, nil
+	}
+	return atlasImage, result.Nodes
+}
+
+// BuildAtlasWithOptions generates an atlas image and returns it, together with the packing that has been chosen.
+// An error is returned if no packing satisfies the options.
+func BuildAtlasWithOptions(images []*image.RGBA, options AtlasOptions) (*image.RGBA, *AtlasResult, error) {
+	return BuildAtlasContext(context.Background(), images, options)
+}
+
+// BuildAtlasContext is like BuildAtlasWithOptions, but the search of the best packing stops, returning the error of
+// the context, as soon as the context is done.
+func BuildAtlasContext(ctx context.Context, images []*image.RGBA, options AtlasOptions) (*image.RGBA, *AtlasResult, error) {
+	sources := images
+	trims := make([]geom.Insets, len(images))
+	if options.Trim {
+		sources, trims = trimImages(images)
+	}
+
+	// For each image, the index of the image which is going to be packed in its place
+	aliases := make([]int, len(sources))
+	for i := range aliases {
+		aliases[i] = i
+	}
+	if options.Deduplicate {
+		aliases = findDuplicateImages(sources)
+	}
+
+	rects := make([]geom.RectNode, 0, len(sources))
+	for i, img := range sources {
+		if aliases[i] == i {
+			rects = append(rects, geom.NewRectNode(i, img.Bounds().Dx(), img.Bounds().Dy()))
+		}
+	}
+
+	result, err := bruteForceAtlasPacking(ctx, rects, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result == nil {
+		return nil, nil, errors.New("the images don't fit in an atlas with the given options")
+	}
+
+	packedNodes := make(map[int]geom.RectNode, len(result.Nodes))
+	for _, node := range result.Nodes {
+		packedNodes[node.Index] = node
+	}
+	result.Sprites = make([]AtlasSprite, len(images))
+	for i, img := range images {
+		result.Sprites[i] = AtlasSprite{
+			RectNode:   packedNodes[aliases[i]],
+			Trim:       trims[i],
+			SourceSize: geom.Size{W: img.Bounds().Dx(), H: img.Bounds().Dy()},
+		}
+	}
+
+	if options.Extrude {
+		result.OffsetX, result.OffsetY = options.PaddingX/2, options.PaddingY/2
+	}
+	atlasImage := drawAtlasImage(sources, result.Nodes, result.Width, result.Height, result.OffsetX, result.OffsetY, options.Extrude)
+	return atlasImage, result, nil
+}
+
+// findDuplicateImages returns, for each image, the index of the first image with the same size and content
+func findDuplicateImages(images []*image.RGBA) []int {
+	aliases := make([]int, len(images))
+	candidates := make(map[uint64][]int)
+	for i, img := range images {
+		aliases[i] = i
+		hash := hashImage(img)
+		for _, j := range candidates[hash] {
+			if imagesEqual(img, images[j]) {
+				aliases[i] = j
+				break
+			}
+		}
+		if aliases[i] == i {
+			candidates[hash] = append(candidates[hash], i)
+		}
+	}
+	return aliases
+}
+
+// hashImage computes a FNV-1a hash of the size and of the pixels of the image
+func hashImage(img *image.RGBA) uint64 {
+	hasher := fnv.New64a()
+	bounds := img.Bounds()
+	_, _ = fmt.Fprintf(hasher, "%dx%d", bounds.Dx(), bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		_, _ = hasher.Write(imageRow(img, y))
+	}
+	return hasher.Sum64()
+}
+
+// imagesEqual checks if the two images have the same size and the same pixels
+func imagesEqual(a, b *image.RGBA) bool {
+	if a.Bounds().Size() != b.Bounds().Size() {
+		return false
+	}
+	for y := 0; y < a.Bounds().Dy(); y++ {
+		if !bytes.Equal(imageRow(a, a.Bounds().Min.Y+y), imageRow(b, b.Bounds().Min.Y+y)) {
+			return false
+		}
+	}
+	return true
+}
+
+// imageRow returns the pixels of the row y of the image
+func imageRow(img *image.RGBA, y int) []byte {
+	start := img.PixOffset(img.Bounds().Min.X, y)
+	return img.Pix[start : start+img.Bounds().Dx()*4]
+}
+
+// trimImages removes the transparent borders of the images, returning the trimmed images and the removed insets.
+// Fully transparent images are reduced to a single pixel.
+func trimImages(images []*image.RGBA) ([]*image.RGBA, []geom.Insets) {
+	trimmed := make([]*image.RGBA, len(images))
+	trims := make([]geom.Insets, len(images))
+	for i, img := range images {
+		trimmed[i], trims[i] = CropTransparentBorders(img)
+		if trimmed[i] == nil {
+			trimmed[i] = image.NewRGBA(image.Rect(0, 0, 1, 1))
+			trims[i] = geom.Insets{Right: imath.Max(img.Bounds().Dx()-1, 0), Bottom: imath.Max(img.Bounds().Dy()-1, 0)}
+		}
+	}
+	return trimmed, trims
+}
+
+// BuildAtlasPages generates as many atlas images as needed to contain all the images, none of them bigger than
+// maxPageWidth x maxPageHeight. It also returns, for each of the input images, its page and how it has been packed.
+// An error is returned if an image, padding included, doesn't fit in an empty page.
+func BuildAtlasPages(images []*image.RGBA, maxPageWidth, maxPageHeight, paddingX, paddingY int, allowRotation bool) ([]*image.RGBA, []AtlasPageNode, error) {
+	pages := make([]*image.RGBA, 0)
+	nodes := make([]AtlasPageNode, len(images))
+
+	rects := make([]geom.RectNode, 0, len(images))
+	for i, img := range images {
+		rects = append(rects, geom.NewRectNode(i, img.Bounds().Dx(), img.Bounds().Dy()))
+	}
+
+	for len(rects) > 0 {
+		result := packAtlasPage(rects, maxPageWidth, maxPageHeight, paddingX, paddingY, allowRotation)
+		if len(result.PlacedRects) == 0 {
+			rect := rects[0]
+			return nil, nil, fmt.Errorf("image %d (%dx%d) doesn't fit in a %dx%d page", rect.Index, rect.W, rect.H, maxPageWidth, maxPageHeight)
+		}
+
+		for _, node := range result.PlacedRects {
+			nodes[node.Index] = AtlasPageNode{RectNode: node, Page: len(pages)}
+		}
+		pages = append(pages, drawAtlasImage(images, result.PlacedRects, result.Width, result.Height, 0, 0, false))
+
+		// The rects that didn't fit go to the next page
+		rects = result.NotPlacedRects
+	}
+
+	return pages, nodes, nil
+}
+
+// packAtlasPage packs as many rects as possible in a single page, trying each of the defaultHeuristics and keeping the
+// one which covers the largest area
+func packAtlasPage(rects []geom.RectNode, pageWidth, pageHeight, paddingX, paddingY int, allowRotation bool) *geom.MaxRectsBinResult {
+	var bestOccupancy float32 = -1
+	var bestResult *geom.MaxRectsBinResult
+
+	for _, method := range defaultHeuristics {
+		// Pack modifies the input slice, every method gets its own copy
+		input := make([]geom.RectNode, len(rects))
+		copy(input, rects)
+
+		maxRects := geom.NewMaxRectsBinPacker(pageWidth, pageHeight, paddingX, paddingY, allowRotation)
+		result := maxRects.Pack(input, method)
+		result.Method = method
+		if maxRects.Occupancy() > bestOccupancy {
+			bestResult = result
+			bestOccupancy = maxRects.Occupancy()
+		}
+	}
+	return bestResult
+}
+
+// drawAtlasImage creates an image of the given size and draws the packed images into it, each one at the given offset
+// from the top-left corner of its node
+func drawAtlasImage(images []*image.RGBA, nodes []geom.RectNode, width, height, offsetX, offsetY int, extrude bool) *image.RGBA {
+	atlasImage := image.NewRGBA(image.Rect(0, 0, width, height))
+	for _, node := range nodes {
 		img := images[node.Index]
 		if node.Rotated {
 			img = Rotate90CW(images[node.Index])
 		}
-		draw.Draw(atlasImage,
-			image.Rectangle{Min: image.Point{X: node.X, Y: node.Y}, Max: image.Point{X: node.X + node.W-1, Y: node.Y + node.H-1}},
-			img,
-			image.Point{},
-			draw.Src,
-		)
+		imageRect := image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()).Add(image.Point{X: node.X + offsetX, Y: node.Y + offsetY})
+		draw.Draw(atlasImage, imageRect, img, img.Bounds().Min, draw.Src)
+		if extrude {
+			extrudeEdges(atlasImage, node.Rect, imageRect)
+		}
 	}
+	return atlasImage
+}
 
-	return atlasImage, result.PlacedRects
+// extrudeEdges fills the area of the node not covered by the image repeating the edges of the image: the columns at
+// its sides first, then the rows above and below, which include the corners
+func extrudeEdges(atlasImage *image.RGBA, node geom.Rect, imageRect image.Rectangle) {
+	if imageRect.Empty() {
+		return
+	}
+	for x := node.X; x < node.Right(); x++ {
+		sourceX := imath.Clamp(x, imageRect.Min.X, imageRect.Max.X-1)
+		if sourceX == x {
+			continue
+		}
+		column := image.Rect(x, imageRect.Min.Y, x+1, imageRect.Max.Y)
+		draw.Draw(atlasImage, column, atlasImage, image.Point{X: sourceX, Y: imageRect.Min.Y}, draw.Src)
+	}
+	for y := node.Y; y < node.Bottom(); y++ {
+		sourceY := imath.Clamp(y, imageRect.Min.Y, imageRect.Max.Y-1)
+		if sourceY == y {
+			continue
+		}
+		row := image.Rect(node.X, y, node.Right(), y+1)
+		draw.Draw(atlasImage, row, atlasImage, image.Point{X: node.X, Y: sourceY}, draw.Src)
+	}
 }
 
-// bruteForceAtlasPacking tries multiple combinations of image sizes and heuristics and then uses the best one
-func bruteForceAtlasPacking(images []*image.RGBA, paddingX, paddingY int, allowRotation bool) (*geom.MaxRectsBinResult, float32) {
-	var bestOccupancy float32 = 0.0
-	var bestResult *geom.MaxRectsBinResult
-	//var bestMethod geom.FreeRectChoiceHeuristic
-	//var bestWidth int
+// bruteForceAtlasPacking tries multiple combinations of image sizes and heuristics and then uses the best one.
+// The combinations are evaluated concurrently, but the result is the same as evaluating them in order: when two of
+// them have the same occupancy, the first one wins.
+// It returns nil if none of the combinations satisfies the options, or the error of the context if it's done.
+func bruteForceAtlasPacking(ctx context.Context, rects []geom.RectNode, options AtlasOptions) (*AtlasResult, error) {
+	minSurface := 0
+	for _, rect := range rects {
+		minSurface += rect.W * rect.H
+	}
+
+	packers := make([]atlasPacker, 0)
+	for i, newPacker := range options.Packers {
+		packers = append(packers, atlasPacker{newPacker: newPacker, index: i})
+	}
+	if len(packers) == 0 {
+		for _, method := range options.Methods {
+			packers = append(packers, atlasPacker{newPacker: newMaxRectsAtlasPacker(method), method: method})
+		}
+	}
 
 	// Tries multiple texture widths, and it leaves the height to the algorithm
-	widths := []int{128, 256, 512, 1024}
-	for _, maxWidth := range widths {
-		// Tries the different methods
-		methods := []geom.FreeRectChoiceHeuristic{geom.RectBestShortSideFit, geom.RectBestAreaFit, geom.RectBottomLeftRule}
-		for method := range methods {
-			done := false
-			sizeFactor := float32(1)
-			var result *geom.MaxRectsBinResult
-			for !done {
-				rects := make([]geom.RectNode, 0)
-				minSurface := 0
-				for i, img := range images {
-					rect := geom.NewRectNode(i, img.Bounds().Dx(), img.Bounds().Dy())
-					rects = append(rects, rect)
-					minSurface += rect.W * rect.H
-				}
-				minSurface = int(float32(minSurface) * sizeFactor)
-				width := maxWidth
-				height := minSurface / width
-
-				maxRects := geom.NewMaxRectsBinPacker(width, height, paddingX, paddingY, allowRotation)
-				result = maxRects.Pack(rects, geom.FreeRectChoiceHeuristic(method))
-				if len(result.NotPlacedRects) > 0 {
-					// Some rects didn't fit in the provided area, increase it a bit
-					sizeFactor += 0.01
-				} else {
-					//fmt.Printf("- Method:%d Occupancy:%f\n", method, maxRects.Occupancy())
-					if maxRects.Occupancy() > bestOccupancy {
-						bestResult = result
-						bestOccupancy = maxRects.Occupancy()
-						//bestMethod = geom.FreeRectChoiceHeuristic(method)
-						//bestWidth = maxWidth
-					}
-					done = true
-					continue
+	type atlasJob struct {
+		width  int
+		packer atlasPacker
+	}
+	jobs := make([]atlasJob, 0)
+	for _, width := range options.Widths {
+		if options.MaxWidth > 0 && width > options.MaxWidth {
+			continue
+		}
+		if !rectsFitInWidth(rects, width, options) {
+			continue
+		}
+		// Tries the different packers
+		for _, packer := range packers {
+			jobs = append(jobs, atlasJob{width: width, packer: packer})
+		}
+	}
+
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = imath.Min(workers, len(jobs))
+
+	// Each worker writes only the results of its own jobs
+	results := make([]*AtlasResult, len(jobs))
+	jobIndexes := make(chan int, len(jobs))
+	for i := range jobs {
+		jobIndexes <- i
+	}
+	close(jobIndexes)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobIndexes {
+				if ctx.Err() != nil {
+					return
 				}
+				results[i] = packAtlasWithWidth(ctx, rects, minSurface, jobs[i].width, jobs[i].packer, options)
 			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var bestResult *AtlasResult
+	for _, result := range results {
+		if result != nil && (bestResult == nil || result.Occupancy > bestResult.Occupancy) {
+			bestResult = result
+		}
+	}
+	return bestResult, nil
+}
+
+// newMaxRectsAtlasPacker returns an AtlasPackerFunc creating MaxRects packers which use the given heuristic
+func newMaxRectsAtlasPacker(method geom.FreeRectChoiceHeuristic) AtlasPackerFunc {
+	return func(width, height int, options AtlasOptions) geom.BinPacker {
+		maxRects := geom.NewMaxRectsBinPacker(width, height, options.PaddingX, options.PaddingY, options.AllowRotation)
+		return maxRects.WithHeuristic(method)
+	}
+}
+
+// rectsFitInWidth checks that each of the rects, on its own, fits horizontally in an atlas of the given width
+func rectsFitInWidth(rects []geom.RectNode, width int, options AtlasOptions) bool {
+	for _, rect := range rects {
+		fits := rect.W+options.PaddingX <= width
+		fitsRotated := options.AllowRotation && rect.H+options.PaddingX <= width
+		if !fits && !fitsRotated {
+			return false
+		}
+	}
+	return true
+}
+
+// packAtlasWithWidth packs the rects in an atlas of the given width, increasing its height until all the rects fit.
+// It returns nil if the rects cannot be packed within the size limits of the options, or if the context is done.
+func packAtlasWithWidth(ctx context.Context, rects []geom.RectNode, minSurface, width int, packer atlasPacker, options AtlasOptions) *AtlasResult {
+	// The atlas is at least as tall as the tallest rect, and stacking all the rects is always tall enough
+	minHeight, maxHeight := 0, 0
+	for _, rect := range rects {
+		rectHeight := rect.H
+		if options.AllowRotation {
+			rectHeight = imath.Min(rect.W, rect.H)
+		}
+		minHeight = imath.Max(minHeight, rectHeight+options.PaddingY)
+		maxHeight += imath.Max(rect.W, rect.H) + options.PaddingY
+	}
+
+	sizeFactor := float32(1)
+	previousHeight := 0
+	for ctx.Err() == nil {
+		height := int(float32(minSurface)*sizeFactor) / width
+		// Each attempt has to be taller than the previous one, even when the area of the rects is tiny
+		height = imath.Max(height, imath.Max(minHeight, previousHeight+1))
+		previousHeight = height
+		if options.Square {
+			height = width
+		}
+		lastAttempt := options.Square || options.GrowthStep <= 0
+		if options.MaxHeight > 0 && height >= options.MaxHeight {
+			height = options.MaxHeight
+			lastAttempt = true
+		}
+
+		// Pack modifies the input slice, every attempt gets its own copy
+		input := make([]geom.RectNode, len(rects))
+		copy(input, rects)
+
+		result := packer.newPacker(width, height, options).Pack(input)
+		if len(result.NotPlacedRects) == 0 {
+			return newAtlasResult(result, packer, options)
 		}
+		if lastAttempt || height >= maxHeight {
+			return nil
+		}
+		// Some rects didn't fit in the provided area, increase it a bit
+		sizeFactor += options.GrowthStep
+	}
+	return nil
+}
+
+// newAtlasResult computes the final size of the atlas, applying the constraints of the options.
+// It returns nil if the size exceeds the limits.
+func newAtlasResult(result *geom.BinPackResult, packer atlasPacker, options AtlasOptions) *AtlasResult {
+	width, height := result.Width, result.Height
+	if options.Square {
+		width = imath.Max(width, height)
+		height = width
+	}
+	if options.PowerOfTwo {
+		width = imath.NextPowerOfTwo(width)
+		height = imath.NextPowerOfTwo(height)
+	}
+	if (options.MaxWidth > 0 && width > options.MaxWidth) || (options.MaxHeight > 0 && height > options.MaxHeight) {
+		return nil
+	}
+
+	usedSurface := 0
+	for _, node := range result.PlacedRects {
+		usedSurface += node.W * node.H
+	}
+	var occupancy float32
+	if width > 0 && height > 0 {
+		occupancy = float32(usedSurface) / float32(width*height)
+	}
+
+	return &AtlasResult{
+		Nodes:     result.PlacedRects,
+		Width:     width,
+		Height:    height,
+		Occupancy: occupancy,
+		Method:    packer.method,
+		Packer:    packer.index,
+		PaddingX:  options.PaddingX,
+		PaddingY:  options.PaddingY,
 	}
-	//fmt.Printf("--> DONE Method:%d width:%d Occupancy:%f\n", bestMethod, bestWidth, bestOccupancy)
-	return bestResult, bestOccupancy
 }
@@ -0,0 +1,285 @@
+package imagex
+
+import (
+	"context"
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/imath"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// newFilledImage creates an image of the given size, where every pixel has the same color
+func newFilledImage(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestBuildAtlasPages(t *testing.T) {
+	images := make([]*image.RGBA, 0)
+	for i := 0; i < 10; i++ {
+		images = append(images, newFilledImage(30, 30, color.RGBA{R: uint8(i * 20), A: 255}))
+	}
+
+	// Each page can hold at most 4 images
+	pages, nodes, err := BuildAtlasPages(images, 64, 64, 2, 2, false)
+	testx.AssertEqual(t, "TestBuildAtlasPages error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasPages pages", 3, len(pages))
+	testx.AssertEqual(t, "TestBuildAtlasPages nodes", len(images), len(nodes))
+
+	imagesPerPage := make([]int, len(pages))
+	for i, node := range nodes {
+		errorText := fmt.Sprintf("TestBuildAtlasPages node #%d", i)
+		testx.AssertEqual(t, errorText+" index", i, node.Index)
+		imagesPerPage[node.Page]++
+
+		page := pages[node.Page]
+		if page.Bounds().Dx() > 64 || page.Bounds().Dy() > 64 {
+			t.Errorf("%s failed: page %d is %v", errorText, node.Page, page.Bounds())
+		}
+		testx.AssertEqual(t, errorText+" color", images[i].RGBAAt(0, 0), page.RGBAAt(node.X, node.Y))
+		testx.AssertEqual(t, errorText+" color", images[i].RGBAAt(29, 29), page.RGBAAt(node.X+29, node.Y+29))
+	}
+	testx.AssertEqual(t, "TestBuildAtlasPages images per page", []int{4, 4, 2}, imagesPerPage)
+}
+
+func TestBuildAtlasPagesTooBig(t *testing.T) {
+	images := []*image.RGBA{newFilledImage(10, 10, color.RGBA{A: 255}), newFilledImage(70, 10, color.RGBA{A: 255})}
+	_, _, err := BuildAtlasPages(images, 64, 64, 0, 0, false)
+	if err == nil {
+		t.Errorf("TestBuildAtlasPagesTooBig failed: expecting an error")
+	}
+}
+
+func TestBuildAtlasWithOptions(t *testing.T) {
+	images := make([]*image.RGBA, 0)
+	for i := 0; i < 20; i++ {
+		images = append(images, newFilledImage(100+i, 90, color.RGBA{G: uint8(i * 10), A: 255}))
+	}
+
+	options := DefaultAtlasOptions()
+	options.Widths = []int{2048}
+	options.PowerOfTwo = true
+	atlas, result, err := BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions size", image.Rect(0, 0, result.Width, result.Height), atlas.Bounds())
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions nodes", len(images), len(result.Nodes))
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions width", 2048, result.Width)
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions power of two height", imath.NextPowerOfTwo(result.Height), result.Height)
+	if result.Occupancy <= 0 || result.Occupancy > 1 {
+		t.Errorf("TestBuildAtlasWithOptions failed: occupancy %f", result.Occupancy)
+	}
+
+	options = DefaultAtlasOptions()
+	options.Square = true
+	_, result, err = BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions square error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions square", result.Width, result.Height)
+
+	options = DefaultAtlasOptions()
+	options.MaxHeight = 100
+	_, _, err = BuildAtlasWithOptions(images, options)
+	if err == nil {
+		t.Errorf("TestBuildAtlasWithOptions failed: expecting an error with MaxHeight:100")
+	}
+
+	// The slower heuristics are only used when requested
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions default methods",
+		[]geom.FreeRectChoiceHeuristic{geom.RectBestShortSideFit, geom.RectBestAreaFit, geom.RectBottomLeftRule},
+		DefaultAtlasOptions().Methods)
+	options = DefaultAtlasOptions()
+	options.Methods = []geom.FreeRectChoiceHeuristic{geom.RectContactPointRule}
+	_, result, err = BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions contact point error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasWithOptions contact point", geom.RectContactPointRule, result.Method)
+}
+
+func TestBuildAtlasEmptyImages(t *testing.T) {
+	// The images have no area, the height of the atlas can't be derived from it
+	images := []*image.RGBA{
+		image.NewRGBA(image.Rect(0, 0, 0, 10)),
+		image.NewRGBA(image.Rect(0, 0, 12, 0)),
+		image.NewRGBA(image.Rect(0, 0, 0, 0)),
+	}
+	options := DefaultAtlasOptions()
+	options.PaddingX = 1
+	options.PaddingY = 1
+	_, result, err := BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasEmptyImages error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasEmptyImages nodes", len(images), len(result.Nodes))
+}
+
+func TestBuildAtlasTrim(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(img, image.Rect(3, 4, 8, 10), image.NewUniform(red), image.Point{}, draw.Src)
+	images := []*image.RGBA{img, newFilledImage(8, 8, color.RGBA{})}
+
+	options := DefaultAtlasOptions()
+	options.Trim = true
+	atlas, result, err := BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasTrim error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasTrim sprites", 2, len(result.Sprites))
+
+	sprite := result.Sprites[0]
+	testx.AssertEqual(t, "TestBuildAtlasTrim size", geom.Size{W: 5, H: 6}, sprite.Size())
+	testx.AssertEqual(t, "TestBuildAtlasTrim insets", geom.Insets{Top: 4, Right: 12, Bottom: 10, Left: 3}, sprite.Trim)
+	testx.AssertEqual(t, "TestBuildAtlasTrim source size", geom.Size{W: 20, H: 20}, sprite.SourceSize)
+	testx.AssertEqual(t, "TestBuildAtlasTrim color", red, atlas.RGBAAt(sprite.X, sprite.Y))
+	testx.AssertEqual(t, "TestBuildAtlasTrim color", red, atlas.RGBAAt(sprite.Right()-1, sprite.Bottom()-1))
+
+	empty := result.Sprites[1]
+	testx.AssertEqual(t, "TestBuildAtlasTrim empty size", geom.Size{W: 1, H: 1}, empty.Size())
+	testx.AssertEqual(t, "TestBuildAtlasTrim empty insets", geom.Insets{Right: 7, Bottom: 7}, empty.Trim)
+}
+
+func TestBuildAtlasDeduplicate(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	images := []*image.RGBA{
+		newFilledImage(16, 16, red),
+		newFilledImage(16, 16, blue),
+		newFilledImage(16, 16, red),
+		newFilledImage(16, 8, red),
+		newFilledImage(16, 16, blue),
+	}
+	// A sub-image, with a different stride, sharing the content of the first image
+	images = append(images, newFilledImage(32, 32, red).SubImage(image.Rect(8, 8, 24, 24)).(*image.RGBA))
+
+	options := DefaultAtlasOptions()
+	options.Deduplicate = true
+	_, result, err := BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasDeduplicate error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasDeduplicate nodes", 3, len(result.Nodes))
+	testx.AssertEqual(t, "TestBuildAtlasDeduplicate sprites", len(images), len(result.Sprites))
+
+	indexes := make([]int, 0)
+	for _, sprite := range result.Sprites {
+		indexes = append(indexes, sprite.Index)
+	}
+	testx.AssertEqual(t, "TestBuildAtlasDeduplicate indexes", []int{0, 1, 0, 3, 1, 0}, indexes)
+	testx.AssertEqual(t, "TestBuildAtlasDeduplicate same node", result.Sprites[0].RectNode, result.Sprites[2].RectNode)
+}
+
+func TestBuildAtlasExtrude(t *testing.T) {
+	// Every pixel has a different color, so that the extruded edges can be told apart
+	newGradientImage := func(w, h int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				img.SetRGBA(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), A: 255})
+			}
+		}
+		return img
+	}
+	// The 60x10 image can only fit in the 40 pixels wide atlas if rotated
+	images := []*image.RGBA{newGradientImage(60, 10), newGradientImage(12, 20), newGradientImage(30, 8)}
+
+	options := DefaultAtlasOptions()
+	options.Widths = []int{40}
+	options.PaddingX = 2
+	options.PaddingY = 3
+	options.AllowRotation = true
+	options.Extrude = true
+	atlas, result, err := BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasExtrude error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasExtrude rotated", true, result.Sprites[0].Rotated)
+	testx.AssertEqual(t, "TestBuildAtlasExtrude offset", [2]int{1, 1}, [2]int{result.OffsetX, result.OffsetY})
+
+	for i, sprite := range result.Sprites {
+		img := images[i]
+		if sprite.Rotated {
+			img = Rotate90CW(img)
+		}
+		w, h := img.Bounds().Dx(), img.Bounds().Dy()
+		// The image is in the middle of the padding, which is filled on all the sides
+		imageX, imageY := sprite.X+result.OffsetX, sprite.Y+result.OffsetY
+		testx.AssertEqual(t, fmt.Sprintf("TestBuildAtlasExtrude sprite #%d top-left", i), img.RGBAAt(0, 0), atlas.RGBAAt(sprite.X, sprite.Y))
+		testx.AssertEqual(t, fmt.Sprintf("TestBuildAtlasExtrude sprite #%d left", i), img.RGBAAt(0, h/2), atlas.RGBAAt(sprite.X, imageY+h/2))
+		testx.AssertEqual(t, fmt.Sprintf("TestBuildAtlasExtrude sprite #%d top", i), img.RGBAAt(w/2, 0), atlas.RGBAAt(imageX+w/2, sprite.Y))
+		for y := sprite.Y; y < sprite.Bottom(); y++ {
+			for x := sprite.X; x < sprite.Right(); x++ {
+				expected := img.RGBAAt(imath.Clamp(x-imageX, 0, w-1), imath.Clamp(y-imageY, 0, h-1))
+				if atlas.RGBAAt(x, y) != expected {
+					t.Errorf("TestBuildAtlasExtrude sprite #%d failed at %d,%d: expected %v, received %v", i, x, y, expected, atlas.RGBAAt(x, y))
+				}
+			}
+		}
+	}
+
+	// The frames point to the images, not to their padding
+	descriptor, err := NewAtlasDescriptor(result, []string{"a", "b", "c"}, "atlas.png")
+	testx.AssertEqual(t, "TestBuildAtlasExtrude descriptor error", nil, err)
+	for i, frame := range descriptor.Frames {
+		sprite := result.Sprites[i]
+		testx.AssertEqual(t, fmt.Sprintf("TestBuildAtlasExtrude frame #%d", i),
+			geom.Point{X: sprite.X + 1, Y: sprite.Y + 1}, geom.Point{X: frame.Frame.X, Y: frame.Frame.Y})
+	}
+}
+
+func TestBuildAtlasWithPackers(t *testing.T) {
+	images := make([]*image.RGBA, 0)
+	for i := 0; i < 30; i++ {
+		images = append(images, newFilledImage(10+i%7*3, 12+i%5*4, color.RGBA{B: uint8(i * 8), A: 255}))
+	}
+
+	options := DefaultAtlasOptions()
+	options.PaddingX = 1
+	options.PaddingY = 1
+	options.Packers = []AtlasPackerFunc{
+		func(width, height int, options AtlasOptions) geom.BinPacker {
+			return geom.NewSkylineBinPacker(width, height, options.PaddingX, options.PaddingY, options.AllowRotation, geom.SkylineMinWasteFit)
+		},
+		func(width, height int, options AtlasOptions) geom.BinPacker {
+			return geom.NewGuillotineBinPacker(width, height, options.PaddingX, options.PaddingY, options.AllowRotation,
+				geom.RectBestAreaFit, geom.SplitShorterLeftoverAxis, true)
+		},
+	}
+	atlas, result, err := BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasWithPackers error", nil, err)
+	testx.AssertEqual(t, "TestBuildAtlasWithPackers nodes", len(images), len(result.Nodes))
+	if result.Packer < 0 || result.Packer > 1 {
+		t.Errorf("TestBuildAtlasWithPackers failed: packer index %d", result.Packer)
+	}
+	for i, sprite := range result.Sprites {
+		testx.AssertEqual(t, fmt.Sprintf("TestBuildAtlasWithPackers sprite #%d", i), images[i].RGBAAt(0, 0), atlas.RGBAAt(sprite.X, sprite.Y))
+	}
+}
+
+func TestBuildAtlasWorkers(t *testing.T) {
+	images := make([]*image.RGBA, 0)
+	for i := 0; i < 40; i++ {
+		images = append(images, newFilledImage(8+i*7%29, 8+i*11%31, color.RGBA{R: uint8(i * 6), A: 255}))
+	}
+
+	options := DefaultAtlasOptions()
+	options.AllowRotation = true
+	options.Workers = 1
+	serialAtlas, serialResult, err := BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestBuildAtlasWorkers serial error", nil, err)
+
+	for _, workers := range []int{0, 3, 16} {
+		options.Workers = workers
+		atlas, result, err := BuildAtlasWithOptions(images, options)
+		errorText := fmt.Sprintf("TestBuildAtlasWorkers workers:%d", workers)
+		testx.AssertEqual(t, errorText+" error", nil, err)
+		testx.AssertEqual(t, errorText+" result", serialResult, result)
+		testx.AssertEqual(t, errorText+" atlas", serialAtlas.Pix, atlas.Pix)
+	}
+}
+
+func TestBuildAtlasContextCancel(t *testing.T) {
+	images := []*image.RGBA{newFilledImage(10, 10, color.RGBA{A: 255})}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := BuildAtlasContext(ctx, images, DefaultAtlasOptions())
+	testx.AssertEqual(t, "TestBuildAtlasContextCancel error", context.Canceled, err)
+}
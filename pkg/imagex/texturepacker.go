@@ -0,0 +1,227 @@
+package imagex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/file"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"io/fs"
+	"sort"
+)
+
+type TexturePackerFormat int
+
+const (
+	// The frames are stored in an object, using their names as keys
+	TexturePackerHash TexturePackerFormat = iota
+	// The frames are stored in an array, each of them with a "filename" field
+	TexturePackerArray
+)
+
+// AtlasDescriptor describes the content of an atlas image
+type AtlasDescriptor struct {
+	Image  string       // Name of the atlas image file
+	Size   geom.Size    // Size of the atlas image
+	Frames []AtlasFrame // Sprites contained in the atlas
+}
+
+// AtlasFrame describes a sprite stored in an atlas, with the same semantic used by TexturePacker
+type AtlasFrame struct {
+	Name             string
+	Frame            geom.Rect // Area of the atlas containing the sprite. W and H are not rotated.
+	Rotated          bool      // True if the sprite has been rotated 90 degrees clockwise
+	Trimmed          bool      // True if the transparent borders of the sprite have been removed
+	SpriteSourceSize geom.Rect // Area of the original image covered by the trimmed sprite
+	SourceSize       geom.Size // Size of the original image
+}
+
+// NewAtlasDescriptor creates the descriptor of an atlas built by BuildAtlasWithOptions.
+// Names are the names of the sprites, in the same order of the images used to build the atlas.
+func NewAtlasDescriptor(result *AtlasResult, names []string, imageName string) (*AtlasDescriptor, error) {
+	if len(names) != len(result.Sprites) {
+		return nil, fmt.Errorf("%d names provided for %d sprites", len(names), len(result.Sprites))
+	}
+
+	descriptor := &AtlasDescriptor{
+		Image:  imageName,
+		Size:   geom.Size{W: result.Width, H: result.Height},
+		Frames: make([]AtlasFrame, 0, len(result.Sprites)),
+	}
+	for i, sprite := range result.Sprites {
+		// The size of the nodes includes the padding, and it's rotated
+		w, h := sprite.W-result.PaddingX, sprite.H-result.PaddingY
+		if sprite.Rotated {
+			w, h = h, w
+		}
+		descriptor.Frames = append(descriptor.Frames, AtlasFrame{
+			Name:             names[i],
+			Frame:            geom.Rect{X: sprite.X + result.OffsetX, Y: sprite.Y + result.OffsetY, W: w, H: h},
+			Rotated:          sprite.Rotated,
+			Trimmed:          sprite.Trim != geom.Insets{},
+			SpriteSourceSize: geom.Rect{X: sprite.Trim.Left, Y: sprite.Trim.Top, W: w, H: h},
+			SourceSize:       sprite.SourceSize,
+		})
+	}
+	return descriptor, nil
+}
+
+// FromTexturePackerJSON parses an atlas descriptor in the TexturePacker JSON format, both hash and array are supported
+func FromTexturePackerJSON(data []byte) (*AtlasDescriptor, error) {
+	var atlas struct {
+		Frames json.RawMessage   `json:"frames"`
+		Meta   texturePackerMeta `json:"meta"`
+	}
+	err := json.Unmarshal(data, &atlas)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]texturePackerFrame, 0)
+	switch firstByte(atlas.Frames) {
+	case '{':
+		framesHash := make(map[string]texturePackerFrame)
+		err = json.Unmarshal(atlas.Frames, &framesHash)
+		if err != nil {
+			return nil, err
+		}
+		for name, frame := range framesHash {
+			frame.Filename = name
+			frames = append(frames, frame)
+		}
+		// The order of the keys of a JSON object is not preserved
+		sort.Slice(frames, func(i, j int) bool { return frames[i].Filename < frames[j].Filename })
+	case '[':
+		err = json.Unmarshal(atlas.Frames, &frames)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("the 'frames' field must be an object or an array")
+	}
+
+	descriptor := &AtlasDescriptor{
+		Image:  atlas.Meta.Image,
+		Size:   geom.Size{W: atlas.Meta.Size.W, H: atlas.Meta.Size.H},
+		Frames: make([]AtlasFrame, 0, len(frames)),
+	}
+	for _, frame := range frames {
+		descriptor.Frames = append(descriptor.Frames, frame.toAtlasFrame())
+	}
+	return descriptor, nil
+}
+
+// ReadTexturePackerJSON reads an atlas descriptor in the TexturePacker JSON format from a file
+func ReadTexturePackerJSON(fileSys fs.FS, filePath string, fileName string) (*AtlasDescriptor, error) {
+	content, err := file.Read(fileSys, filePath, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return FromTexturePackerJSON(content)
+}
+
+// ToTexturePackerJSON serializes the descriptor in one of the TexturePacker JSON formats.
+// The hash format requires the names of the frames to be unique.
+func (d *AtlasDescriptor) ToTexturePackerJSON(format TexturePackerFormat) ([]byte, error) {
+	meta := texturePackerMeta{
+		App:     "https://github.com/maxfish/go-libs",
+		Version: "1.0",
+		Image:   d.Image,
+		Format:  "RGBA8888",
+		Size:    texturePackerSize{W: d.Size.W, H: d.Size.H},
+		Scale:   "1",
+	}
+
+	switch format {
+	case TexturePackerHash:
+		frames := make(map[string]texturePackerFrame, len(d.Frames))
+		for _, frame := range d.Frames {
+			// The frames are indexed by name, a duplicate would replace the previous one
+			if _, exists := frames[frame.Name]; exists {
+				return nil, fmt.Errorf("duplicate frame name %q", frame.Name)
+			}
+			tpFrame := newTexturePackerFrame(frame)
+			tpFrame.Filename = ""
+			frames[frame.Name] = tpFrame
+		}
+		return json.MarshalIndent(struct {
+			Frames map[string]texturePackerFrame `json:"frames"`
+			Meta   texturePackerMeta             `json:"meta"`
+		}{frames, meta}, "", "\t")
+	case TexturePackerArray:
+		frames := make([]texturePackerFrame, 0, len(d.Frames))
+		for _, frame := range d.Frames {
+			frames = append(frames, newTexturePackerFrame(frame))
+		}
+		return json.MarshalIndent(struct {
+			Frames []texturePackerFrame `json:"frames"`
+			Meta   texturePackerMeta    `json:"meta"`
+		}{frames, meta}, "", "\t")
+	default:
+		return nil, fmt.Errorf("unknown TexturePacker format %d", format)
+	}
+}
+
+// === JSON structures of the TexturePacker format
+
+type texturePackerRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type texturePackerSize struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type texturePackerFrame struct {
+	Filename         string            `json:"filename,omitempty"`
+	Frame            texturePackerRect `json:"frame"`
+	Rotated          bool              `json:"rotated"`
+	Trimmed          bool              `json:"trimmed"`
+	SpriteSourceSize texturePackerRect `json:"spriteSourceSize"`
+	SourceSize       texturePackerSize `json:"sourceSize"`
+}
+
+type texturePackerMeta struct {
+	App     string            `json:"app"`
+	Version string            `json:"version"`
+	Image   string            `json:"image"`
+	Format  string            `json:"format"`
+	Size    texturePackerSize `json:"size"`
+	Scale   string            `json:"scale"`
+}
+
+func newTexturePackerFrame(frame AtlasFrame) texturePackerFrame {
+	return texturePackerFrame{
+		Filename:         frame.Name,
+		Frame:            texturePackerRect{X: frame.Frame.X, Y: frame.Frame.Y, W: frame.Frame.W, H: frame.Frame.H},
+		Rotated:          frame.Rotated,
+		Trimmed:          frame.Trimmed,
+		SpriteSourceSize: texturePackerRect{X: frame.SpriteSourceSize.X, Y: frame.SpriteSourceSize.Y, W: frame.SpriteSourceSize.W, H: frame.SpriteSourceSize.H},
+		SourceSize:       texturePackerSize{W: frame.SourceSize.W, H: frame.SourceSize.H},
+	}
+}
+
+func (f texturePackerFrame) toAtlasFrame() AtlasFrame {
+	return AtlasFrame{
+		Name:             f.Filename,
+		Frame:            geom.Rect{X: f.Frame.X, Y: f.Frame.Y, W: f.Frame.W, H: f.Frame.H},
+		Rotated:          f.Rotated,
+		Trimmed:          f.Trimmed,
+		SpriteSourceSize: geom.Rect{X: f.SpriteSourceSize.X, Y: f.SpriteSourceSize.Y, W: f.SpriteSourceSize.W, H: f.SpriteSourceSize.H},
+		SourceSize:       geom.Size{W: f.SourceSize.W, H: f.SourceSize.H},
+	}
+}
+
+// firstByte returns the first non-whitespace byte of the JSON value, or 0 if it's empty
+func firstByte(data json.RawMessage) byte {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return 0
+	}
+	return data[0]
+}
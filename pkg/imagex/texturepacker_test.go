@@ -0,0 +1,125 @@
+package imagex
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/geom"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"testing/fstest"
+)
+
+const texturePackerHashSample = `{"frames": {
+	"hero.png": {
+		"frame": {"x":2,"y":4,"w":30,"h":40},
+		"rotated": true,
+		"trimmed": true,
+		"spriteSourceSize": {"x":1,"y":3,"w":30,"h":40},
+		"sourceSize": {"w":32,"h":48}
+	},
+	"coin.png": {
+		"frame": {"x":50,"y":0,"w":16,"h":16},
+		"rotated": false,
+		"trimmed": false,
+		"spriteSourceSize": {"x":0,"y":0,"w":16,"h":16},
+		"sourceSize": {"w":16,"h":16}
+	}},
+	"meta": {"app": "https://www.codeandweb.com/texturepacker", "image": "sprites.png", "size": {"w":128,"h":64}, "scale": "1"}
+}`
+
+func TestReadTexturePackerJSON(t *testing.T) {
+	fileSys := fstest.MapFS{"atlas/sprites.json": &fstest.MapFile{Data: []byte(texturePackerHashSample)}}
+	descriptor, err := ReadTexturePackerJSON(fileSys, "atlas", "sprites.json")
+	testx.AssertEqual(t, "TestReadTexturePackerJSON error", nil, err)
+
+	expected := &AtlasDescriptor{
+		Image: "sprites.png",
+		Size:  geom.Size{W: 128, H: 64},
+		Frames: []AtlasFrame{
+			{
+				Name:             "coin.png",
+				Frame:            geom.Rect{X: 50, Y: 0, W: 16, H: 16},
+				SpriteSourceSize: geom.Rect{W: 16, H: 16},
+				SourceSize:       geom.Size{W: 16, H: 16},
+			},
+			{
+				Name:             "hero.png",
+				Frame:            geom.Rect{X: 2, Y: 4, W: 30, H: 40},
+				Rotated:          true,
+				Trimmed:          true,
+				SpriteSourceSize: geom.Rect{X: 1, Y: 3, W: 30, H: 40},
+				SourceSize:       geom.Size{W: 32, H: 48},
+			},
+		},
+	}
+	testx.AssertEqual(t, "TestReadTexturePackerJSON descriptor", expected, descriptor)
+
+	_, err = FromTexturePackerJSON([]byte(`{"frames": 3}`))
+	if err == nil {
+		t.Errorf("TestReadTexturePackerJSON failed: expecting an error for invalid frames")
+	}
+}
+
+func TestTexturePackerJSONRoundTrip(t *testing.T) {
+	images := make([]*image.RGBA, 0)
+	names := make([]string, 0)
+	for i := 0; i < 8; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, 20+i*4, 10))
+		draw.Draw(img, image.Rect(1, 2, 10+i*4, 9), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+		images = append(images, img)
+		names = append(names, fmt.Sprintf("sprite_%02d.png", i))
+	}
+
+	options := DefaultAtlasOptions()
+	options.PaddingX = 1
+	options.PaddingY = 2
+	options.AllowRotation = true
+	options.Trim = true
+	_, result, err := BuildAtlasWithOptions(images, options)
+	testx.AssertEqual(t, "TestTexturePackerJSONRoundTrip atlas error", nil, err)
+
+	descriptor, err := NewAtlasDescriptor(result, names, "atlas.png")
+	testx.AssertEqual(t, "TestTexturePackerJSONRoundTrip descriptor error", nil, err)
+	for i, frame := range descriptor.Frames {
+		errorText := fmt.Sprintf("TestTexturePackerJSONRoundTrip frame #%d", i)
+		testx.AssertEqual(t, errorText+" size", geom.Size{W: 9 + i*4, H: 7}, frame.Frame.Size())
+		testx.AssertEqual(t, errorText+" source", geom.Rect{X: 1, Y: 2, W: 9 + i*4, H: 7}, frame.SpriteSourceSize)
+		testx.AssertEqual(t, errorText+" trimmed", true, frame.Trimmed)
+	}
+
+	for _, format := range []TexturePackerFormat{TexturePackerHash, TexturePackerArray} {
+		data, err := descriptor.ToTexturePackerJSON(format)
+		testx.AssertEqual(t, fmt.Sprintf("TestTexturePackerJSONRoundTrip format %d write error", format), nil, err)
+		readDescriptor, err := FromTexturePackerJSON(data)
+		testx.AssertEqual(t, fmt.Sprintf("TestTexturePackerJSONRoundTrip format %d read error", format), nil, err)
+		testx.AssertEqual(t, fmt.Sprintf("TestTexturePackerJSONRoundTrip format %d", format), descriptor, readDescriptor)
+	}
+
+	_, err = NewAtlasDescriptor(result, names[1:], "atlas.png")
+	if err == nil {
+		t.Errorf("TestTexturePackerJSONRoundTrip failed: expecting an error for missing names")
+	}
+}
+
+func TestTexturePackerJSONDuplicateNames(t *testing.T) {
+	descriptor := &AtlasDescriptor{
+		Image: "atlas.png",
+		Size:  geom.Size{W: 32, H: 16},
+		Frames: []AtlasFrame{
+			{Name: "sprite.png", Frame: geom.Rect{W: 16, H: 16}, SourceSize: geom.Size{W: 16, H: 16}},
+			{Name: "sprite.png", Frame: geom.Rect{X: 16, W: 16, H: 16}, SourceSize: geom.Size{W: 16, H: 16}},
+		},
+	}
+	_, err := descriptor.ToTexturePackerJSON(TexturePackerHash)
+	if err == nil {
+		t.Errorf("TestTexturePackerJSONDuplicateNames failed: expecting an error for the hash format")
+	}
+	// The array format keeps both frames
+	data, err := descriptor.ToTexturePackerJSON(TexturePackerArray)
+	testx.AssertEqual(t, "TestTexturePackerJSONDuplicateNames array error", nil, err)
+	readDescriptor, err := FromTexturePackerJSON(data)
+	testx.AssertEqual(t, "TestTexturePackerJSONDuplicateNames read error", nil, err)
+	testx.AssertEqual(t, "TestTexturePackerJSONDuplicateNames array", 2, len(readDescriptor.Frames))
+}
@@ -45,3 +45,12 @@ func Sign(value int) int {
 	}
 	return 1
 }
+
+// NextPowerOfTwo returns the smallest power of two greater than or equal to value
+func NextPowerOfTwo(value int) int {
+	result := 1
+	for result < value {
+		result <<= 1
+	}
+	return result
+}
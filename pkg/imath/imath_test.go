@@ -50,3 +50,22 @@ func TestMin(t *testing.T) {
 		}
 	}
 }
+
+func TestNextPowerOfTwo(t *testing.T) {
+	var tests = []struct{ value, result int }{
+		{value: -5, result: 1},
+		{value: 0, result: 1},
+		{value: 1, result: 1},
+		{value: 2, result: 2},
+		{value: 3, result: 4},
+		{value: 1024, result: 1024},
+		{value: 1025, result: 2048},
+	}
+
+	for _, test := range tests {
+		valueGot := NextPowerOfTwo(test.value)
+		if valueGot != test.result {
+			t.Errorf("Got %d, expecting %d", valueGot, test.result)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package rand
+
+import (
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"github.com/maxfish/go-libs/pkg/imath"
+	"math"
+)
+
+// CellularDistance is the metric used to measure the distance from the feature points of the cellular noise
+type CellularDistance int
+
+const (
+	CellularEuclidean CellularDistance = iota
+	CellularManhattan
+	CellularChebyshev
+)
+
+// CellularResult is a sample of the cellular noise
+type CellularResult struct {
+	// The distances from the nearest and the second nearest feature points
+	F1, F2 float32
+	// The hash of the cell containing the nearest feature point, the same for all the samples closest to it
+	CellID uint32
+}
+
+// CellularNoise2D is the Worley noise: each cell of size 1 contains a feature point, placed by hashing the cell
+// coordinates with HashNoise2D. It returns the distances from the two nearest feature points, and the cell of the
+// nearest one.
+func CellularNoise2D(x, y float32, seed uint32, distance CellularDistance) CellularResult {
+	cellX, cellY := int(fmath.Floor(x)), int(fmath.Floor(y))
+	result := CellularResult{F1: math.MaxFloat32, F2: math.MaxFloat32}
+
+	// The cells are searched in rings around the one containing the sample. The points in the ring r are at least
+	// r-1 away, so the search stops when they can't be closer than F2.
+	for ring := 0; float32(ring-1) < result.F2; ring++ {
+		for offsetY := -ring; offsetY <= ring; offsetY++ {
+			for offsetX := -ring; offsetX <= ring; offsetX++ {
+				if imath.Max(imath.Abs(offsetX), imath.Abs(offsetY)) != ring {
+					continue
+				}
+				pointCellX, pointCellY := cellX+offsetX, cellY+offsetY
+				cellID := HashNoise2D(uint32(pointCellX), uint32(pointCellY), seed)
+				pointX := float32(pointCellX) + cellularJitter(cellID)
+				pointY := float32(pointCellY) + cellularJitter(cellID>>16)
+				result.add(cellularDistance(distance, pointX-x, pointY-y, 0), cellID)
+			}
+		}
+	}
+	return result
+}
+
+// CellularNoise3D is the Worley noise in 3D, with the feature points placed by hashing the cell coordinates with
+// HashNoise3D. See CellularNoise2D.
+func CellularNoise3D(x, y, z float32, seed uint32, distance CellularDistance) CellularResult {
+	cellX, cellY, cellZ := int(fmath.Floor(x)), int(fmath.Floor(y)), int(fmath.Floor(z))
+	result := CellularResult{F1: math.MaxFloat32, F2: math.MaxFloat32}
+
+	for ring := 0; float32(ring-1) < result.F2; ring++ {
+		for offsetZ := -ring; offsetZ <= ring; offsetZ++ {
+			for offsetY := -ring; offsetY <= ring; offsetY++ {
+				for offsetX := -ring; offsetX <= ring; offsetX++ {
+					if imath.Max(imath.Abs(offsetX), imath.Max(imath.Abs(offsetY), imath.Abs(offsetZ))) != ring {
+						continue
+					}
+					pointCellX, pointCellY, pointCellZ := cellX+offsetX, cellY+offsetY, cellZ+offsetZ
+					cellID := HashNoise3D(uint32(pointCellX), uint32(pointCellY), uint32(pointCellZ), seed)
+					pointX := float32(pointCellX) + cellularJitter(cellID)
+					pointY := float32(pointCellY) + cellularJitter(cellID>>16)
+					pointZ := float32(pointCellZ) + cellularJitter(HashNoise(cellID, seed))
+					result.add(cellularDistance(distance, pointX-x, pointY-y, pointZ-z), cellID)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// add keeps the feature point if it's one of the two nearest
+func (r *CellularResult) add(distance float32, cellID uint32) {
+	if distance < r.F1 {
+		r.F2 = r.F1
+		r.F1, r.CellID = distance, cellID
+	} else if distance < r.F2 {
+		r.F2 = distance
+	}
+}
+
+// cellularJitter turns the lowest 16 bits of the hash into an offset in the range [0, 1)
+func cellularJitter(hash uint32) float32 {
+	return float32(hash&0xFFFF) / 0x10000
+}
+
+func cellularDistance(distance CellularDistance, deltaX, deltaY, deltaZ float32) float32 {
+	switch distance {
+	case CellularManhattan:
+		return fmath.Abs(deltaX) + fmath.Abs(deltaY) + fmath.Abs(deltaZ)
+	case CellularChebyshev:
+		return fmath.Max(fmath.Abs(deltaX), fmath.Max(fmath.Abs(deltaY), fmath.Abs(deltaZ)))
+	default:
+		return fmath.Sqrt(deltaX*deltaX + deltaY*deltaY + deltaZ*deltaZ)
+	}
+}
@@ -0,0 +1,97 @@
+package rand
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/fmath"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"math"
+	"testing"
+)
+
+// bruteForceCellular2D checks all the feature points in a large area around the sample
+func bruteForceCellular2D(x, y float32, seed uint32, distance CellularDistance) CellularResult {
+	result := CellularResult{F1: math.MaxFloat32, F2: math.MaxFloat32}
+	cellX, cellY := int(fmath.Floor(x)), int(fmath.Floor(y))
+	for pointCellY := cellY - 3; pointCellY <= cellY+3; pointCellY++ {
+		for pointCellX := cellX - 3; pointCellX <= cellX+3; pointCellX++ {
+			cellID := HashNoise2D(uint32(pointCellX), uint32(pointCellY), seed)
+			pointX := float32(pointCellX) + cellularJitter(cellID)
+			pointY := float32(pointCellY) + cellularJitter(cellID>>16)
+			result.add(cellularDistance(distance, pointX-x, pointY-y, 0), cellID)
+		}
+	}
+	return result
+}
+
+func bruteForceCellular3D(x, y, z float32, seed uint32, distance CellularDistance) CellularResult {
+	result := CellularResult{F1: math.MaxFloat32, F2: math.MaxFloat32}
+	cellX, cellY, cellZ := int(fmath.Floor(x)), int(fmath.Floor(y)), int(fmath.Floor(z))
+	for pointCellZ := cellZ - 3; pointCellZ <= cellZ+3; pointCellZ++ {
+		for pointCellY := cellY - 3; pointCellY <= cellY+3; pointCellY++ {
+			for pointCellX := cellX - 3; pointCellX <= cellX+3; pointCellX++ {
+				cellID := HashNoise3D(uint32(pointCellX), uint32(pointCellY), uint32(pointCellZ), seed)
+				pointX := float32(pointCellX) + cellularJitter(cellID)
+				pointY := float32(pointCellY) + cellularJitter(cellID>>16)
+				pointZ := float32(pointCellZ) + cellularJitter(HashNoise(cellID, seed))
+				result.add(cellularDistance(distance, pointX-x, pointY-y, pointZ-z), cellID)
+			}
+		}
+	}
+	return result
+}
+
+func TestCellularNoise(t *testing.T) {
+	rng := NewHashRngWithSeed(0)
+	coord := func() float32 { return rng.NextFloat32()*200 - 100 }
+	for i := 0; i < 3000; i++ {
+		x, y, z := coord(), coord(), coord()
+		for _, distance := range []CellularDistance{CellularEuclidean, CellularManhattan, CellularChebyshev} {
+			testIndex := fmt.Sprintf("%d/%d", i, distance)
+			testx.AssertEqual(t, "CellularNoise2D() #"+testIndex, bruteForceCellular2D(x, y, 89021, distance), CellularNoise2D(x, y, 89021, distance))
+			testx.AssertEqual(t, "CellularNoise3D() #"+testIndex, bruteForceCellular3D(x, y, z, 89021, distance), CellularNoise3D(x, y, z, 89021, distance))
+		}
+	}
+}
+
+func TestCellularNoiseFeaturePoint(t *testing.T) {
+	// Sampling at the feature point of a cell
+	cellID := HashNoise2D(3, 5, 89021)
+	x, y := 3+cellularJitter(cellID), 5+cellularJitter(cellID>>16)
+	result := CellularNoise2D(x, y, 89021, CellularEuclidean)
+	testx.AssertEqual(t, "CellularNoise2D() F1", float32(0), result.F1)
+	testx.AssertEqual(t, "CellularNoise2D() CellID", cellID, result.CellID)
+	if result.F2 <= 0 {
+		t.Errorf("CellularNoise2D() F2 = %v, expected > 0", result.F2)
+	}
+	if CellularNoise2D(x, y, 1, CellularEuclidean) == CellularNoise2D(x, y, 2, CellularEuclidean) {
+		t.Errorf("CellularNoise2D() doesn't depend on the seed")
+	}
+}
+
+// === Benchmarks
+
+// goos: linux
+// goarch: amd64
+// pkg: github.com/maxfish/go-libs/pkg/rand
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkCellularNoise2D
+// BenchmarkCellularNoise2D    	 6540888	       198.6 ns/op
+func BenchmarkCellularNoise2D(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CellularNoise2D(float32(i)*0.1, float32(i)*0.07, 89021, CellularEuclidean)
+	}
+}
+
+// goos: linux
+// goarch: amd64
+// pkg: github.com/maxfish/go-libs/pkg/rand
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkCellularNoise3D
+// BenchmarkCellularNoise3D    	 1959729	       602.4 ns/op
+func BenchmarkCellularNoise3D(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CellularNoise3D(float32(i)*0.1, float32(i)*0.07, float32(i)*0.03, 89021, CellularEuclidean)
+	}
+}
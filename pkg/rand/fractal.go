@@ -0,0 +1,77 @@
+package rand
+
+import (
+	"github.com/maxfish/go-libs/pkg/fmath"
+)
+
+// FractalOctaveCallback samples one octave of a noise function, in the range [-1, 1]. The coordinates have to be
+// multiplied by the frequency, the octave index can be used to change the seed of each octave. E.g.:
+//
+//	func(frequency float32, octave int) float32 {
+//		return PerlinNoise2D(x*frequency, y*frequency, seed+int32(octave))
+//	}
+type FractalOctaveCallback func(frequency float32, octave int) float32
+
+// FractalOptions controls how the octaves of a fractal noise are combined
+type FractalOptions struct {
+	// The number of octaves summed up
+	Octaves int
+	// The factor applied to the frequency at each octave
+	Lacunarity float32
+	// The factor applied to the amplitude at each octave
+	Persistence float32
+}
+
+// DefaultFractalOptions returns 4 octaves, each with double the frequency and half the amplitude of the previous one
+func DefaultFractalOptions() FractalOptions {
+	return FractalOptions{Octaves: 4, Lacunarity: 2, Persistence: 0.5}
+}
+
+// Feedback of each ridged octave on the weight of the next one
+const ridgedGain = 2
+
+// FractalBrownianMotion sums up the octaves of the noise. The result is normalized by the sum of the amplitudes,
+// so it stays in the range [-1, 1].
+func FractalBrownianMotion(options FractalOptions, noise FractalOctaveCallback) float32 {
+	return sumOctaves(options, func(frequency float32, octave int, _ float32) (float32, float32) {
+		return noise(frequency, octave), 1
+	})
+}
+
+// Turbulence sums up the absolute values of the octaves of the noise, which creates creases where the noise crosses
+// zero. The result is normalized in the range [0, 1].
+func Turbulence(options FractalOptions, noise FractalOctaveCallback) float32 {
+	return sumOctaves(options, func(frequency float32, octave int, _ float32) (float32, float32) {
+		return fmath.Abs(noise(frequency, octave)), 1
+	})
+}
+
+// RidgedMultifractal sums up the octaves of the noise turned into sharp ridges, as described by F. Kenton Musgrave.
+// Each octave is weighted by the previous one, so the details gather along the ridges and the valleys stay smooth.
+// The result is normalized in the range [0, 1].
+func RidgedMultifractal(options FractalOptions, noise FractalOctaveCallback) float32 {
+	return sumOctaves(options, func(frequency float32, octave int, weight float32) (float32, float32) {
+		signal := 1 - fmath.Abs(noise(frequency, octave))
+		signal *= signal * weight
+		return signal, fmath.Clamp(signal*ridgedGain, 0, 1)
+	})
+}
+
+// sumOctaves adds up the octaves returned by the callback, scaled by their amplitude and normalized by the sum of the
+// amplitudes. The callback receives the weight returned by the previous octave (1 for the first one).
+func sumOctaves(options FractalOptions, octave func(frequency float32, octave int, weight float32) (float32, float32)) float32 {
+	var sum, amplitudeSum float32
+	frequency, amplitude, weight := float32(1), float32(1), float32(1)
+	for i := 0; i < options.Octaves; i++ {
+		var value float32
+		value, weight = octave(frequency, i, weight)
+		sum += value * amplitude
+		amplitudeSum += amplitude
+		frequency *= options.Lacunarity
+		amplitude *= options.Persistence
+	}
+	if amplitudeSum == 0 {
+		return 0
+	}
+	return sum / amplitudeSum
+}
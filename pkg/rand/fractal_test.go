@@ -0,0 +1,89 @@
+package rand
+
+import (
+	"fmt"
+	"github.com/maxfish/go-libs/pkg/testx"
+	"testing"
+)
+
+func TestFractalOctaves(t *testing.T) {
+	frequencies := make([]float32, 0)
+	octaves := make([]int, 0)
+	FractalBrownianMotion(FractalOptions{Octaves: 4, Lacunarity: 3, Persistence: 0.5}, func(frequency float32, octave int) float32 {
+		frequencies = append(frequencies, frequency)
+		octaves = append(octaves, octave)
+		return 0
+	})
+	testx.AssertEqual(t, "FractalOctaves() frequencies", []float32{1, 3, 9, 27}, frequencies)
+	testx.AssertEqual(t, "FractalOctaves() octaves", []int{0, 1, 2, 3}, octaves)
+}
+
+func TestFractalNormalization(t *testing.T) {
+	options := DefaultFractalOptions()
+	constant := func(value float32) FractalOctaveCallback {
+		return func(frequency float32, octave int) float32 { return value }
+	}
+	// Each octave alternates between 1 and -1
+	alternating := func(frequency float32, octave int) float32 { return float32(1 - 2*(octave%2)) }
+
+	var tests = []struct {
+		name     string
+		expected float32
+		received float32
+	}{
+		{"FractalBrownianMotion", 0.5, FractalBrownianMotion(options, constant(0.5))},
+		{"FractalBrownianMotion", -1, FractalBrownianMotion(options, constant(-1))},
+		// (1 - 0.5 + 0.25 - 0.125) / (1 + 0.5 + 0.25 + 0.125)
+		{"FractalBrownianMotion", 0.625 / 1.875, FractalBrownianMotion(options, alternating)},
+		{"Turbulence", 0.5, Turbulence(options, constant(-0.5))},
+		{"Turbulence", 1, Turbulence(options, alternating)},
+		{"RidgedMultifractal", 1, RidgedMultifractal(options, constant(0))},
+		{"RidgedMultifractal", 0, RidgedMultifractal(options, constant(1))},
+		// Each octave is 0.25, weighted by twice the previous one: (0.25 + 0.5*0.125 + 0.25*0.0625 + 0.125*0.03125) / 1.875
+		{"RidgedMultifractal", 0.33203125 / 1.875, RidgedMultifractal(options, constant(0.5))},
+		{"Empty", 0, FractalBrownianMotion(FractalOptions{}, constant(1))},
+	}
+	for index, test := range tests {
+		testIndex := fmt.Sprintf("%d", index)
+		testx.AssertEqual(t, test.name+"() #"+testIndex, test.expected, test.received)
+	}
+}
+
+func TestFractalRange(t *testing.T) {
+	rng := NewHashRngWithSeed(0)
+	options := FractalOptions{Octaves: 6, Lacunarity: 2, Persistence: 0.6}
+	for i := 0; i < 10000; i++ {
+		x, y := rng.NextFloat32()*100, rng.NextFloat32()*100
+		noise := func(frequency float32, octave int) float32 {
+			return PerlinNoise2D(x*frequency, y*frequency, 89021+int32(octave))
+		}
+		if value := FractalBrownianMotion(options, noise); value < -1 || value > 1 {
+			t.Fatalf("FractalBrownianMotion(%v, %v) = %v is outside [-1, 1]", x, y, value)
+		}
+		if value := Turbulence(options, noise); value < 0 || value > 1 {
+			t.Fatalf("Turbulence(%v, %v) = %v is outside [0, 1]", x, y, value)
+		}
+		if value := RidgedMultifractal(options, noise); value < 0 || value > 1 {
+			t.Fatalf("RidgedMultifractal(%v, %v) = %v is outside [0, 1]", x, y, value)
+		}
+	}
+}
+
+// === Benchmarks
+
+// goos: linux
+// goarch: amd64
+// pkg: github.com/maxfish/go-libs/pkg/rand
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkFractalBrownianMotion
+// BenchmarkFractalBrownianMotion    	 3373249	       319.5 ns/op
+func BenchmarkFractalBrownianMotion(b *testing.B) {
+	options := DefaultFractalOptions()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x, y := float32(i)*0.1, float32(i)*0.07
+		FractalBrownianMotion(options, func(frequency float32, octave int) float32 {
+			return PerlinNoise2D(x*frequency, y*frequency, 89021+int32(octave))
+		})
+	}
+}
@@ -21,3 +21,9 @@ func HashNoise2D(x, y, seed uint32) (value uint32) {
 	const primeNumber uint32 = 0xBD4BCB5
 	return HashNoise(x + (primeNumber * y), seed)
 }
+
+func HashNoise3D(x, y, z, seed uint32) (value uint32) {
+	const primeNumber1 uint32 = 0xBD4BCB5
+	const primeNumber2 uint32 = 0x63D68D
+	return HashNoise(x+(primeNumber1*y)+(primeNumber2*z), seed)
+}
@@ -114,3 +114,436 @@ func grad(hash int32, x float32) float32 {
 	}
 	return grad * x // Multiply the gradient with the distance
 }
+
+/**
+ * 2D Perlin simplex noise
+ * @param[in] x float coordinate
+ * @param[in] y float coordinate
+ * @return Noise value in the range[-1; 1].
+ */
+func PerlinNoise2D(x, y float32, seed int32) float32 {
+	var n0, n1, n2 float32 // Noise contributions from the three corners
+
+	// Skewing/Unskewing factors for 2D
+	const F2 = 0.366025403 // F2 = (sqrt(3) - 1) / 2
+	const G2 = 0.211324865 // G2 = (3 - sqrt(3)) / 6   = F2 / (1 + 2 * K)
+
+	// Skew the input space to determine which simplex cell we're in
+	var s = (x + y) * F2 // Hairy factor for 2D
+	var xs = x + s
+	var ys = y + s
+	var i = int32(fmath.Floor(xs))
+	var j = int32(fmath.Floor(ys))
+
+	// Unskew the cell origin back to (x,y) space
+	var t = float32(i+j) * G2
+	var X0 = float32(i) - t
+	var Y0 = float32(j) - t
+	var x0 = x - X0 // The x,y distances from the cell origin
+	var y0 = y - Y0
+
+	// For the 2D case, the simplex shape is an equilateral triangle.
+	// Determine which simplex we are in.
+	var i1, j1 int32 // Offsets for second (middle) corner of simplex in (i,j) coords
+	if x0 > y0 {     // lower triangle, XY order: (0,0)->(1,0)->(1,1)
+		i1 = 1
+		j1 = 0
+	} else { // upper triangle, YX order: (0,0)->(0,1)->(1,1)
+		i1 = 0
+		j1 = 1
+	}
+
+	// A step of (1,0) in (i,j) means a step of (1-c,-c) in (x,y), and
+	// a step of (0,1) in (i,j) means a step of (-c,1-c) in (x,y), where
+	// c = (3-sqrt(3))/6
+
+	var x1 = x0 - float32(i1) + G2 // Offsets for middle corner in (x,y) unskewed coords
+	var y1 = y0 - float32(j1) + G2
+	var x2 = x0 - 1.0 + 2.0*G2 // Offsets for last corner in (x,y) unskewed coords
+	var y2 = y0 - 1.0 + 2.0*G2
+
+	// Work out the hashed gradient indices of the three simplex corners
+	var gi0 = hash(i + hash(j+seed*7))
+	var gi1 = hash(i + i1 + hash(j+j1+seed*7))
+	var gi2 = hash(i + 1 + hash(j+1+seed*7))
+
+	// Calculate the contribution from the first corner
+	var t0 = 0.5 - x0*x0 - y0*y0
+	if t0 < 0.0 {
+		n0 = 0.0
+	} else {
+		t0 *= t0
+		n0 = t0 * t0 * grad2(gi0, x0, y0)
+	}
+
+	// Calculate the contribution from the second corner
+	var t1 = 0.5 - x1*x1 - y1*y1
+	if t1 < 0.0 {
+		n1 = 0.0
+	} else {
+		t1 *= t1
+		n1 = t1 * t1 * grad2(gi1, x1, y1)
+	}
+
+	// Calculate the contribution from the third corner
+	var t2 = 0.5 - x2*x2 - y2*y2
+	if t2 < 0.0 {
+		n2 = 0.0
+	} else {
+		t2 *= t2
+		n2 = t2 * t2 * grad2(gi2, x2, y2)
+	}
+
+	// Add contributions from each corner to get the final noise value.
+	// The result is scaled to return values in the interval [-1,1].
+	return 45.23065 * (n0 + n1 + n2)
+}
+
+/**
+ * 3D Perlin simplex noise
+ * @param[in] x float coordinate
+ * @param[in] y float coordinate
+ * @param[in] z float coordinate
+ * @return Noise value in the range[-1; 1].
+ */
+func PerlinNoise3D(x, y, z float32, seed int32) float32 {
+	var n0, n1, n2, n3 float32 // Noise contributions from the four corners
+
+	// Skewing/Unskewing factors for 3D
+	const F3 = 1.0 / 3.0
+	const G3 = 1.0 / 6.0
+
+	// Skew the input space to determine which simplex cell we're in
+	var s = (x + y + z) * F3 // Very nice and simple skew factor for 3D
+	var i = int32(fmath.Floor(x + s))
+	var j = int32(fmath.Floor(y + s))
+	var k = int32(fmath.Floor(z + s))
+	var t = float32(i+j+k) * G3
+	var X0 = float32(i) - t // Unskew the cell origin back to (x,y,z) space
+	var Y0 = float32(j) - t
+	var Z0 = float32(k) - t
+	var x0 = x - X0 // The x,y,z distances from the cell origin
+	var y0 = y - Y0
+	var z0 = z - Z0
+
+	// For the 3D case, the simplex shape is a slightly irregular tetrahedron.
+	// Determine which simplex we are in.
+	var i1, j1, k1 int32 // Offsets for second corner of simplex in (i,j,k) coords
+	var i2, j2, k2 int32 // Offsets for third corner of simplex in (i,j,k) coords
+	if x0 >= y0 {
+		if y0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0 // X Y Z order
+		} else if x0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1 // X Z Y order
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1 // Z X Y order
+		}
+	} else { // x0<y0
+		if y0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1 // Z Y X order
+		} else if x0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1 // Y Z X order
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0 // Y X Z order
+		}
+	}
+
+	// A step of (1,0,0) in (i,j,k) means a step of (1-c,-c,-c) in (x,y,z),
+	// a step of (0,1,0) in (i,j,k) means a step of (-c,1-c,-c) in (x,y,z), and
+	// a step of (0,0,1) in (i,j,k) means a step of (-c,-c,1-c) in (x,y,z), where
+	// c = 1/6.
+	var x1 = x0 - float32(i1) + G3 // Offsets for second corner in (x,y,z) coords
+	var y1 = y0 - float32(j1) + G3
+	var z1 = z0 - float32(k1) + G3
+	var x2 = x0 - float32(i2) + 2.0*G3 // Offsets for third corner in (x,y,z) coords
+	var y2 = y0 - float32(j2) + 2.0*G3
+	var z2 = z0 - float32(k2) + 2.0*G3
+	var x3 = x0 - 1.0 + 3.0*G3 // Offsets for last corner in (x,y,z) coords
+	var y3 = y0 - 1.0 + 3.0*G3
+	var z3 = z0 - 1.0 + 3.0*G3
+
+	// Work out the hashed gradient indices of the four simplex corners
+	var gi0 = hash(i + hash(j+hash(k+seed*7)))
+	var gi1 = hash(i + i1 + hash(j+j1+hash(k+k1+seed*7)))
+	var gi2 = hash(i + i2 + hash(j+j2+hash(k+k2+seed*7)))
+	var gi3 = hash(i + 1 + hash(j+1+hash(k+1+seed*7)))
+
+	// Calculate the contribution from the four corners
+	var t0 = 0.6 - x0*x0 - y0*y0 - z0*z0
+	if t0 < 0 {
+		n0 = 0.0
+	} else {
+		t0 *= t0
+		n0 = t0 * t0 * grad3(gi0, x0, y0, z0)
+	}
+	var t1 = 0.6 - x1*x1 - y1*y1 - z1*z1
+	if t1 < 0 {
+		n1 = 0.0
+	} else {
+		t1 *= t1
+		n1 = t1 * t1 * grad3(gi1, x1, y1, z1)
+	}
+	var t2 = 0.6 - x2*x2 - y2*y2 - z2*z2
+	if t2 < 0 {
+		n2 = 0.0
+	} else {
+		t2 *= t2
+		n2 = t2 * t2 * grad3(gi2, x2, y2, z2)
+	}
+	var t3 = 0.6 - x3*x3 - y3*y3 - z3*z3
+	if t3 < 0 {
+		n3 = 0.0
+	} else {
+		t3 *= t3
+		n3 = t3 * t3 * grad3(gi3, x3, y3, z3)
+	}
+	// Add contributions from each corner to get the final noise value.
+	// The result is scaled to stay just inside [-1,1]
+	return 32.0 * (n0 + n1 + n2 + n3)
+}
+
+/**
+ * 4D Perlin simplex noise
+ *
+ * The original library stops at 3D: this one follows SimplexNoise1234 by Stefan Gustavson, which the 2D and 3D
+ * versions are based on, with the same permutation table and seed.
+ *
+ * @param[in] x float coordinate
+ * @param[in] y float coordinate
+ * @param[in] z float coordinate
+ * @param[in] w float coordinate
+ * @return Noise value in the range[-1; 1].
+ */
+func PerlinNoise4D(x, y, z, w float32, seed int32) float32 {
+	var n0, n1, n2, n3, n4 float32 // Noise contributions from the five corners
+
+	// Skewing/Unskewing factors for 4D
+	const F4 = 0.309016994 // F4 = (sqrt(5) - 1) / 4
+	const G4 = 0.138196601 // G4 = (5 - sqrt(5)) / 20
+
+	// Skew the (x,y,z,w) space to determine which cell of 24 simplices we're in
+	var s = (x + y + z + w) * F4 // Factor for 4D skewing
+	var i = int32(fmath.Floor(x + s))
+	var j = int32(fmath.Floor(y + s))
+	var k = int32(fmath.Floor(z + s))
+	var l = int32(fmath.Floor(w + s))
+	var t = float32(i+j+k+l) * G4 // Factor for 4D unskewing
+	var X0 = float32(i) - t       // Unskew the cell origin back to (x,y,z,w) space
+	var Y0 = float32(j) - t
+	var Z0 = float32(k) - t
+	var W0 = float32(l) - t
+	var x0 = x - X0 // The x,y,z,w distances from the cell origin
+	var y0 = y - Y0
+	var z0 = z - Z0
+	var w0 = w - W0
+
+	// For the 4D case, the simplex is a 4D shape I won't even try to describe.
+	// To find out which of the 24 possible simplices we're in, we need to
+	// determine the magnitude ordering of x0, y0, z0 and w0.
+	// The rank of each coordinate is the number of the other ones it's bigger than.
+	var rankX, rankY, rankZ, rankW int32
+	if x0 > y0 {
+		rankX++
+	} else {
+		rankY++
+	}
+	if x0 > z0 {
+		rankX++
+	} else {
+		rankZ++
+	}
+	if x0 > w0 {
+		rankX++
+	} else {
+		rankW++
+	}
+	if y0 > z0 {
+		rankY++
+	} else {
+		rankZ++
+	}
+	if y0 > w0 {
+		rankY++
+	} else {
+		rankW++
+	}
+	if z0 > w0 {
+		rankZ++
+	} else {
+		rankW++
+	}
+
+	// The integer offsets for the second, third and fourth simplex corners.
+	// The coordinate with rank 3 is the first one to be stepped, then the one with rank 2, and so on.
+	var i1, j1, k1, l1 = rankStep(rankX, 3), rankStep(rankY, 3), rankStep(rankZ, 3), rankStep(rankW, 3)
+	var i2, j2, k2, l2 = rankStep(rankX, 2), rankStep(rankY, 2), rankStep(rankZ, 2), rankStep(rankW, 2)
+	var i3, j3, k3, l3 = rankStep(rankX, 1), rankStep(rankY, 1), rankStep(rankZ, 1), rankStep(rankW, 1)
+
+	// The fifth corner has all coordinate offsets = 1, so no need to look that up.
+	var x1 = x0 - float32(i1) + G4 // Offsets for second corner in (x,y,z,w) coords
+	var y1 = y0 - float32(j1) + G4
+	var z1 = z0 - float32(k1) + G4
+	var w1 = w0 - float32(l1) + G4
+	var x2 = x0 - float32(i2) + 2.0*G4 // Offsets for third corner in (x,y,z,w) coords
+	var y2 = y0 - float32(j2) + 2.0*G4
+	var z2 = z0 - float32(k2) + 2.0*G4
+	var w2 = w0 - float32(l2) + 2.0*G4
+	var x3 = x0 - float32(i3) + 3.0*G4 // Offsets for fourth corner in (x,y,z,w) coords
+	var y3 = y0 - float32(j3) + 3.0*G4
+	var z3 = z0 - float32(k3) + 3.0*G4
+	var w3 = w0 - float32(l3) + 3.0*G4
+	var x4 = x0 - 1.0 + 4.0*G4 // Offsets for last corner in (x,y,z,w) coords
+	var y4 = y0 - 1.0 + 4.0*G4
+	var z4 = z0 - 1.0 + 4.0*G4
+	var w4 = w0 - 1.0 + 4.0*G4
+
+	// Work out the hashed gradient indices of the five simplex corners
+	var gi0 = hash(i + hash(j+hash(k+hash(l+seed*7))))
+	var gi1 = hash(i + i1 + hash(j+j1+hash(k+k1+hash(l+l1+seed*7))))
+	var gi2 = hash(i + i2 + hash(j+j2+hash(k+k2+hash(l+l2+seed*7))))
+	var gi3 = hash(i + i3 + hash(j+j3+hash(k+k3+hash(l+l3+seed*7))))
+	var gi4 = hash(i + 1 + hash(j+1+hash(k+1+hash(l+1+seed*7))))
+
+	// Calculate the contribution from the five corners
+	var t0 = 0.6 - x0*x0 - y0*y0 - z0*z0 - w0*w0
+	if t0 < 0 {
+		n0 = 0.0
+	} else {
+		t0 *= t0
+		n0 = t0 * t0 * grad4(gi0, x0, y0, z0, w0)
+	}
+	var t1 = 0.6 - x1*x1 - y1*y1 - z1*z1 - w1*w1
+	if t1 < 0 {
+		n1 = 0.0
+	} else {
+		t1 *= t1
+		n1 = t1 * t1 * grad4(gi1, x1, y1, z1, w1)
+	}
+	var t2 = 0.6 - x2*x2 - y2*y2 - z2*z2 - w2*w2
+	if t2 < 0 {
+		n2 = 0.0
+	} else {
+		t2 *= t2
+		n2 = t2 * t2 * grad4(gi2, x2, y2, z2, w2)
+	}
+	var t3 = 0.6 - x3*x3 - y3*y3 - z3*z3 - w3*w3
+	if t3 < 0 {
+		n3 = 0.0
+	} else {
+		t3 *= t3
+		n3 = t3 * t3 * grad4(gi3, x3, y3, z3, w3)
+	}
+	var t4 = 0.6 - x4*x4 - y4*y4 - z4*z4 - w4*w4
+	if t4 < 0 {
+		n4 = 0.0
+	} else {
+		t4 *= t4
+		n4 = t4 * t4 * grad4(gi4, x4, y4, z4, w4)
+	}
+
+	// Sum up and scale the result to cover the range [-1,1]
+	return 27.0 * (n0 + n1 + n2 + n3 + n4)
+}
+
+// rankStep returns 1 if the coordinate with the given rank has to be stepped at the given corner of the 4D simplex
+func rankStep(rank, threshold int32) int32 {
+	if rank >= threshold {
+		return 1
+	}
+	return 0
+}
+
+/**
+ * Helper functions to compute gradients-dot-residual vectors (2D)
+ *
+ * The original library masks the hash with 0x3F, which picks the swapped gradients most of the time:
+ * the 3 bits follow SimplexNoise1234, like the 4D version.
+ *
+ * @param[in] hash  hash value
+ * @param[in] x     x coord of the distance to the corner
+ * @param[in] y     y coord of the distance to the corner
+ *
+ * @return gradient value
+ */
+func grad2(hash int32, x, y float32) float32 {
+	var h = hash & 7 // Convert low 3 bits of hash code
+	var u, v = y, x  // into 8 simple gradient directions,
+	if h < 4 {
+		u, v = x, y
+	}
+	if (h & 1) != 0 { // and compute the dot product with (x,y).
+		u = -u
+	}
+	if (h & 2) != 0 {
+		v = -v
+	}
+	return u + 2.0*v
+}
+
+/**
+ * Helper functions to compute gradients-dot-residual vectors (3D)
+ *
+ * @param[in] hash  hash value
+ * @param[in] x     x coord of the distance to the corner
+ * @param[in] y     y coord of the distance to the corner
+ * @param[in] z     z coord of the distance to the corner
+ *
+ * @return gradient value
+ */
+func grad3(hash int32, x, y, z float32) float32 {
+	var h = hash & 15 // Convert low 4 bits of hash code into 12 simple
+	var u = y         // gradient directions, and compute dot product.
+	if h < 8 {
+		u = x
+	}
+	var v = z // Fix repeats at h = 12 to 15
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if (h & 1) != 0 {
+		u = -u
+	}
+	if (h & 2) != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+/**
+ * Helper functions to compute gradients-dot-residual vectors (4D)
+ *
+ * @param[in] hash  hash value
+ * @param[in] x     x coord of the distance to the corner
+ * @param[in] y     y coord of the distance to the corner
+ * @param[in] z     z coord of the distance to the corner
+ * @param[in] w     w coord of the distance to the corner
+ *
+ * @return gradient value
+ */
+func grad4(hash int32, x, y, z, w float32) float32 {
+	var h = hash & 31 // Convert low 5 bits of hash code into 32 simple
+	var u = y         // gradient directions, and compute dot product.
+	if h < 24 {
+		u = x
+	}
+	var v = z
+	if h < 16 {
+		v = y
+	}
+	var t = w
+	if h < 8 {
+		t = z
+	}
+	if (h & 1) != 0 {
+		u = -u
+	}
+	if (h & 2) != 0 {
+		v = -v
+	}
+	if (h & 4) != 0 {
+		t = -t
+	}
+	return u + v + t
+}
@@ -1,9 +1,59 @@
 package rand
 
 import (
+	"github.com/maxfish/go-libs/pkg/testx"
 	"testing"
 )
 
+func TestPerlinNoiseRange(t *testing.T) {
+	rng := NewHashRngWithSeed(0)
+	coord := func() float32 { return float32(rng.NextUint32()%20000)/100 - 100 }
+	for i := 0; i < 100000; i++ {
+		x, y, z, w := coord(), coord(), coord(), coord()
+		values := [3]float32{PerlinNoise2D(x, y, 89021), PerlinNoise3D(x, y, z, 89021), PerlinNoise4D(x, y, z, w, 89021)}
+		for dimensions, value := range values {
+			if value < -1 || value > 1 {
+				t.Fatalf("PerlinNoise%dD(%v, %v, %v, %v) = %v is outside [-1, 1]", dimensions+2, x, y, z, w, value)
+			}
+		}
+	}
+}
+
+func TestPerlinNoiseSeed(t *testing.T) {
+	if PerlinNoise2D(0.5, 0.3, 1) != PerlinNoise2D(0.5, 0.3, 1) {
+		t.Errorf("PerlinNoise2D() isn't deterministic")
+	}
+	if PerlinNoise2D(0.5, 0.3, 1) == PerlinNoise2D(0.5, 0.3, 2) {
+		t.Errorf("PerlinNoise2D() doesn't depend on the seed")
+	}
+	if PerlinNoise3D(0.5, 0.3, 0.7, 1) == PerlinNoise3D(0.5, 0.3, 0.7, 2) {
+		t.Errorf("PerlinNoise3D() doesn't depend on the seed")
+	}
+	if PerlinNoise4D(0.5, 0.3, 0.7, 0.1, 1) == PerlinNoise4D(0.5, 0.3, 0.7, 0.1, 2) {
+		t.Errorf("PerlinNoise4D() doesn't depend on the seed")
+	}
+}
+
+func TestPerlinNoise2DGradients(t *testing.T) {
+	// Counts how many corners of the simplex grid use each gradient direction
+	usage := make(map[[2]float32]int)
+	for i := int32(0); i < 256; i++ {
+		for j := int32(0); j < 256; j++ {
+			gi := hash(i + hash(j+89021*7))
+			usage[[2]float32{grad2(gi, 1, 0), grad2(gi, 0, 1)}]++
+		}
+	}
+	testx.AssertEqual(t, "PerlinNoise2D() gradient directions", 8, len(usage))
+	for direction, count := range usage {
+		// Each direction is used by about 1/8 of the corners
+		if count < 256*256/16 {
+			t.Errorf("PerlinNoise2D() gradient %v used by %d corners only", direction, count)
+		}
+	}
+}
+
+// === Benchmarks
+
 // goos: darwin
 // goarch: amd64
 // pkg: github.com/maxfish/go-libs/pkg/rand
@@ -16,3 +66,42 @@ func BenchmarkPerlinNoise1D(b *testing.B) {
 		PerlinNoise1D(float32(i), 89021)
 	}
 }
+
+// goos: linux
+// goarch: amd64
+// pkg: github.com/maxfish/go-libs/pkg/rand
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkPerlinNoise2D
+// BenchmarkPerlinNoise2D    	23779582	        46.03 ns/op
+func BenchmarkPerlinNoise2D(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PerlinNoise2D(float32(i)*0.1, float32(i)*0.07, 89021)
+	}
+}
+
+// goos: linux
+// goarch: amd64
+// pkg: github.com/maxfish/go-libs/pkg/rand
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkPerlinNoise3D
+// BenchmarkPerlinNoise3D    	 9020284	       124.0 ns/op
+func BenchmarkPerlinNoise3D(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PerlinNoise3D(float32(i)*0.1, float32(i)*0.07, float32(i)*0.03, 89021)
+	}
+}
+
+// goos: linux
+// goarch: amd64
+// pkg: github.com/maxfish/go-libs/pkg/rand
+// cpu: Intel(R) Xeon(R) Processor
+// BenchmarkPerlinNoise4D
+// BenchmarkPerlinNoise4D    	 6091730	       193.2 ns/op
+func BenchmarkPerlinNoise4D(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PerlinNoise4D(float32(i)*0.1, float32(i)*0.07, float32(i)*0.03, float32(i)*0.05, 89021)
+	}
+}